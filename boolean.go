@@ -0,0 +1,351 @@
+package toothpaste
+
+import (
+	"math"
+	"sort"
+)
+
+// Boolean operations split every edge of both operands where it meets
+// another, then keep the pieces that separate the inside of the result
+// from the outside and link them back up into rings
+
+type BooleanOp int
+
+const (
+	UnionOp BooleanOp = iota
+	IntersectionOp
+	DifferenceOp
+	XorOp
+)
+
+func (f *Face2D) Union(other *Face2D) []*Shape2D {
+	return NewShape2D(f).Boolean(NewShape2D(other), UnionOp)
+}
+
+func (f *Face2D) Intersection(other *Face2D) []*Shape2D {
+	return NewShape2D(f).Boolean(NewShape2D(other), IntersectionOp)
+}
+
+func (f *Face2D) Difference(other *Face2D) []*Shape2D {
+	return NewShape2D(f).Boolean(NewShape2D(other), DifferenceOp)
+}
+
+func (f *Face2D) Xor(other *Face2D) []*Shape2D {
+	return NewShape2D(f).Boolean(NewShape2D(other), XorOp)
+}
+
+func (s *Shape2D) Union(other *Shape2D) []*Shape2D {
+	return s.Boolean(other, UnionOp)
+}
+
+func (s *Shape2D) Intersection(other *Shape2D) []*Shape2D {
+	return s.Boolean(other, IntersectionOp)
+}
+
+func (s *Shape2D) Difference(other *Shape2D) []*Shape2D {
+	return s.Boolean(other, DifferenceOp)
+}
+
+func (s *Shape2D) Xor(other *Shape2D) []*Shape2D {
+	return s.Boolean(other, XorOp)
+}
+
+// Boolean combines two shapes, holes included. The result can be any
+// number of shapes, each with its outer counter-clockwise and its holes
+// clockwise, and the vertices are all new
+func (s *Shape2D) Boolean(other *Shape2D, op BooleanOp) []*Shape2D {
+	a, b := s.Faces(), other.Faces()
+	inside := func(x, y float64) bool {
+		inA, inB := insideRings(x, y, a), insideRings(x, y, b)
+		switch op {
+		case UnionOp:
+			return inA || inB
+		case IntersectionOp:
+			return inA && inB
+		case DifferenceOp:
+			return inA && !inB
+		default:
+			return inA != inB
+		}
+	}
+	return fillRings(append(a, b...), inside)
+}
+
+// insideRings uses the even-odd rule across all the rings, so a point
+// inside an outer and one of its holes is outside
+func insideRings(x, y float64, rings []*Face2D) bool {
+	inside := false
+	for _, ring := range rings {
+		if pointInRing(x, y, ring.Vertices) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// fillRings rebuilds the region described by inside, using the edges of
+// rings as the only places its boundary can be. Every edge is split
+// where it crosses or touches another, and each piece is kept if the
+// region is on exactly one side of it
+func fillRings(rings []*Face2D, inside func(x, y float64) bool) []*Shape2D {
+	var edges [][2]*Vertex2D
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, ring := range rings {
+		for i, v := range ring.Vertices {
+			next := ring.Vertices[(i+1)%len(ring.Vertices)]
+			if v.X != next.X || v.Y != next.Y {
+				edges = append(edges, [2]*Vertex2D{v, next})
+			}
+			minX, minY = math.Min(minX, v.X), math.Min(minY, v.Y)
+			maxX, maxY = math.Max(maxX, v.X), math.Max(maxY, v.Y)
+		}
+	}
+	if len(edges) == 0 {
+		return nil
+	}
+	size := math.Max(maxX-minX, maxY-minY)
+	if size == 0 {
+		return nil
+	}
+	tolerance := size * 1e-9
+
+	pool := newPointPool(tolerance)
+	pieces := map[[2]int]bool{}
+	for i, e := range edges {
+		ts := []float64{0, 1}
+		for j, o := range edges {
+			if i != j {
+				ts = append(ts, splitParams(e[0], e[1], o[0], o[1], tolerance)...)
+			}
+		}
+		sort.Float64s(ts)
+		prev := -1
+		for _, t := range ts {
+			id := pool.add(e[0].X+(e[1].X-e[0].X)*t, e[0].Y+(e[1].Y-e[0].Y)*t)
+			if prev != -1 && prev != id {
+				key := [2]int{prev, id}
+				if key[0] > key[1] {
+					key[0], key[1] = key[1], key[0]
+				}
+				pieces[key] = true
+			}
+			prev = id
+		}
+	}
+
+	// sort so the output doesn't depend on map order
+	keys := make([][2]int, 0, len(pieces))
+	for key := range pieces {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	step := size * 1e-7
+	var directed [][2]int
+	for _, key := range keys {
+		a, b := pool.points[key[0]], pool.points[key[1]]
+		length := a.Distance(b)
+		s := math.Min(step, length*1e-3)
+		nx, ny := -(b.Y-a.Y)/length*s, (b.X-a.X)/length*s
+		mx, my := (a.X+b.X)/2, (a.Y+b.Y)/2
+		left, right := inside(mx+nx, my+ny), inside(mx-nx, my-ny)
+		if left && !right {
+			directed = append(directed, key)
+		} else if right && !left {
+			directed = append(directed, [2]int{key[1], key[0]})
+		}
+	}
+	return shapesFromLoops(linkEdges(directed, pool.points), tolerance)
+}
+
+// splitParams returns where along a-b the segment c-d crosses or touches
+func splitParams(a, b, c, d *Vertex2D, tolerance float64) []float64 {
+	if math.Max(c.X, d.X) < math.Min(a.X, b.X)-tolerance || math.Min(c.X, d.X) > math.Max(a.X, b.X)+tolerance ||
+		math.Max(c.Y, d.Y) < math.Min(a.Y, b.Y)-tolerance || math.Min(c.Y, d.Y) > math.Max(a.Y, b.Y)+tolerance {
+		return nil
+	}
+	var ts []float64
+	rx, ry := b.X-a.X, b.Y-a.Y
+	sx, sy := d.X-c.X, d.Y-c.Y
+	denom := rx*sy - ry*sx
+	lengthSq := rx*rx + ry*ry
+	if math.Abs(denom) > 1e-12*math.Sqrt(lengthSq*(sx*sx+sy*sy)) {
+		t := ((c.X-a.X)*sy - (c.Y-a.Y)*sx) / denom
+		u := ((c.X-a.X)*ry - (c.Y-a.Y)*rx) / denom
+		if t > 0 && t < 1 && u >= 0 && u <= 1 {
+			ts = append(ts, t)
+		}
+	}
+	// endpoints of the other segment lying on this one, which covers
+	// touching and overlapping segments
+	for _, p := range []*Vertex2D{c, d} {
+		t := ((p.X-a.X)*rx + (p.Y-a.Y)*ry) / lengthSq
+		if t <= 0 || t >= 1 {
+			continue
+		}
+		if math.Hypot(a.X+rx*t-p.X, a.Y+ry*t-p.Y) <= tolerance {
+			ts = append(ts, t)
+		}
+	}
+	return ts
+}
+
+// pointPool merges points closer than the tolerance, using a grid so
+// that only neighbouring cells need checking
+type pointPool struct {
+	tolerance float64
+	points    []*Vertex2D
+	grid      map[[2]int64][]int
+}
+
+func newPointPool(tolerance float64) *pointPool {
+	return &pointPool{tolerance: tolerance, grid: map[[2]int64][]int{}}
+}
+
+func (p *pointPool) add(x, y float64) int {
+	if id := p.find(x, y); id != -1 {
+		return id
+	}
+	cell := [2]int64{int64(math.Floor(x / p.tolerance)), int64(math.Floor(y / p.tolerance))}
+	id := len(p.points)
+	p.points = append(p.points, NewVertex2D(x, y))
+	p.grid[cell] = append(p.grid[cell], id)
+	return id
+}
+
+// find returns the point within the tolerance, or -1
+func (p *pointPool) find(x, y float64) int {
+	cx, cy := int64(math.Floor(x/p.tolerance)), int64(math.Floor(y/p.tolerance))
+	for dx := int64(-1); dx <= 1; dx++ {
+		for dy := int64(-1); dy <= 1; dy++ {
+			for _, id := range p.grid[[2]int64{cx + dx, cy + dy}] {
+				if math.Hypot(p.points[id].X-x, p.points[id].Y-y) <= p.tolerance {
+					return id
+				}
+			}
+		}
+	}
+	return -1
+}
+
+// linkEdges joins directed edges into closed loops. Where several edges
+// leave the same point the sharpest left turn is taken, which keeps
+// loops that only touch at a point separate
+func linkEdges(edges [][2]int, points []*Vertex2D) [][]*Vertex2D {
+	out := map[int][]int{}
+	for i, e := range edges {
+		out[e[0]] = append(out[e[0]], i)
+	}
+	used := make([]bool, len(edges))
+	var loops [][]*Vertex2D
+	for start := range edges {
+		if used[start] {
+			continue
+		}
+		used[start] = true
+		loop := []int{edges[start][0]}
+		prev, cur := edges[start][0], edges[start][1]
+		closed := false
+		for {
+			if cur == edges[start][0] {
+				closed = true
+				break
+			}
+			loop = append(loop, cur)
+			back := math.Atan2(points[prev].Y-points[cur].Y, points[prev].X-points[cur].X)
+			next, best := -1, math.Inf(1)
+			for _, i := range out[cur] {
+				if used[i] {
+					continue
+				}
+				to := points[edges[i][1]]
+				// clockwise angle from the way we came in
+				angle := back - math.Atan2(to.Y-points[cur].Y, to.X-points[cur].X)
+				for angle <= 0 {
+					angle += 2 * math.Pi
+				}
+				if angle < best {
+					next, best = i, angle
+				}
+			}
+			if next == -1 {
+				break
+			}
+			used[next] = true
+			prev, cur = cur, edges[next][1]
+		}
+		if !closed || len(loop) < 3 {
+			continue
+		}
+		vertices := make([]*Vertex2D, len(loop))
+		for i, id := range loop {
+			vertices[i] = points[id].Copy()
+		}
+		loops = append(loops, vertices)
+	}
+	return loops
+}
+
+// removeCollinear2D drops vertices that lie on the line between their
+// neighbours, such as those left behind where an edge was split
+func removeCollinear2D(vertices []*Vertex2D, tolerance float64) []*Vertex2D {
+	for changed := true; changed && len(vertices) > 3; {
+		changed = false
+		for i := 0; i < len(vertices) && len(vertices) > 3; i++ {
+			prev := vertices[(i+len(vertices)-1)%len(vertices)]
+			v, next := vertices[i], vertices[(i+1)%len(vertices)]
+			length := prev.Distance(next)
+			if length == 0 {
+				continue
+			}
+			cross := (v.X-prev.X)*(next.Y-prev.Y) - (v.Y-prev.Y)*(next.X-prev.X)
+			dot := (v.X-prev.X)*(next.X-prev.X) + (v.Y-prev.Y)*(next.Y-prev.Y)
+			if math.Abs(cross)/length <= tolerance && dot > 0 && dot < length*length {
+				vertices = append(vertices[:i], vertices[i+1:]...)
+				changed = true
+				i--
+			}
+		}
+	}
+	return vertices
+}
+
+// shapesFromLoops sorts loops into outers (counter-clockwise) and holes
+// (clockwise), giving each hole to the smallest outer around it
+func shapesFromLoops(loops [][]*Vertex2D, tolerance float64) []*Shape2D {
+	var shapes []*Shape2D
+	var areas []float64
+	var holes []*Face2D
+	for _, loop := range loops {
+		loop = removeCollinear2D(loop, tolerance)
+		area := signedArea(loop)
+		if len(loop) < 3 || math.Abs(area) <= tolerance*tolerance {
+			continue
+		}
+		if area > 0 {
+			shapes = append(shapes, NewShape2D(&Face2D{Vertices: loop}))
+			areas = append(areas, area)
+		} else {
+			holes = append(holes, &Face2D{Vertices: loop})
+		}
+	}
+	for _, hole := range holes {
+		sample := ringSample(hole.Vertices)
+		best := -1
+		for i, shape := range shapes {
+			if pointInRing(sample.X, sample.Y, shape.Outer.Vertices) && (best == -1 || areas[i] < areas[best]) {
+				best = i
+			}
+		}
+		if best != -1 {
+			shapes[best].Holes = append(shapes[best].Holes, hole)
+		}
+	}
+	return shapes
+}
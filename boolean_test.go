@@ -0,0 +1,80 @@
+package toothpaste
+
+import (
+	"math"
+	"testing"
+)
+
+func shapesArea(shapes []*Shape2D) float64 {
+	area := 0.0
+	for _, s := range shapes {
+		area += signedArea(s.Outer.Vertices)
+		for _, h := range s.Holes {
+			area += signedArea(h.Vertices)
+		}
+	}
+	return area
+}
+
+func TestBooleanOps(t *testing.T) {
+	a := Square(2, 2)
+	b := Square(2, 2)
+	b.Translate(1, 1)
+	for _, test := range []struct {
+		name   string
+		shapes []*Shape2D
+		count  int
+		area   float64
+	}{
+		{"union", a.Union(b), 1, 7},
+		{"intersection", a.Intersection(b), 1, 1},
+		{"difference", a.Difference(b), 1, 3},
+		{"xor", a.Xor(b), 2, 6},
+	} {
+		if len(test.shapes) != test.count {
+			t.Errorf("Expected %v to give %v shapes, got %v", test.name, test.count, len(test.shapes))
+		}
+		if area := shapesArea(test.shapes); math.Abs(area-test.area) > 1e-9 {
+			t.Errorf("Expected %v area %v, got %v", test.name, test.area, area)
+		}
+	}
+	if n := len(a.Union(b)[0].Outer.Vertices); n != 8 {
+		t.Errorf("Expected the union to have 8 corners, got %v", n)
+	}
+}
+
+func TestBooleanHoles(t *testing.T) {
+	outer := Square(4, 4)
+	inner := Square(2, 2)
+	inner.Translate(1, 1)
+
+	// cutting out the middle makes a hole
+	shapes := outer.Difference(inner)
+	if len(shapes) != 1 || len(shapes[0].Holes) != 1 {
+		t.Fatalf("Expected 1 shape with 1 hole, got %v", shapes)
+	}
+	if signedArea(shapes[0].Holes[0].Vertices) >= 0 {
+		t.Errorf("Expected the hole to be clockwise")
+	}
+
+	// filling in part of the hole shrinks it
+	patch := Square(1, 2)
+	patch.Translate(1, 1)
+	filled := shapes[0].Union(NewShape2D(patch))
+	if len(filled) != 1 || len(filled[0].Holes) != 1 {
+		t.Fatalf("Expected 1 shape with 1 hole, got %v", filled)
+	}
+	if area := shapesArea(filled); math.Abs(area-14) > 1e-9 {
+		t.Errorf("Expected area 14, got %v", area)
+	}
+
+	// disjoint shapes stay separate
+	far := Square(1, 1)
+	far.Translate(10, 0)
+	if n := len(outer.Union(far)); n != 2 {
+		t.Errorf("Expected 2 shapes, got %v", n)
+	}
+	if n := len(outer.Intersection(far)); n != 0 {
+		t.Errorf("Expected no shapes, got %v", n)
+	}
+}
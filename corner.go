@@ -0,0 +1,102 @@
+package toothpaste
+
+import (
+	"math"
+)
+
+// Fillet rounds corners of the face with arcs of the given radius, each
+// made of segments straight pieces. Corners can be chosen by index or by
+// label, otherwise all of them are rounded. Where the edges next to a
+// corner are too short for the radius it is reduced to fit, sharing
+// each edge with the corner at its other end if that is rounded too.
+// Returns a new face, vertices that aren't rounded keep their labels
+func (f *Face2D) Fillet(radius float64, segments int, corners ...interface{}) *Face2D {
+	if segments < 1 {
+		segments = 1
+	}
+	return f.cutCorners(corners, func(v *Vertex2D, d1, d2 [2]float64, limit, half float64) []*Vertex2D {
+		r := math.Min(radius, limit*math.Tan(half))
+		t := r / math.Tan(half)
+		a := NewVertex2D(v.X+d1[0]*t, v.Y+d1[1]*t)
+		b := NewVertex2D(v.X+d2[0]*t, v.Y+d2[1]*t)
+		bx, by := d1[0]+d2[0], d1[1]+d2[1]
+		length := math.Hypot(bx, by)
+		dist := r / math.Sin(half)
+		cx, cy := v.X+bx/length*dist, v.Y+by/length*dist
+		start := math.Atan2(a.Y-cy, a.X-cx)
+		sweep := math.Atan2(b.Y-cy, b.X-cx) - start
+		if sweep > math.Pi {
+			sweep -= 2 * math.Pi
+		} else if sweep < -math.Pi {
+			sweep += 2 * math.Pi
+		}
+		points := []*Vertex2D{a}
+		for i := 1; i < segments; i++ {
+			angle := start + sweep*float64(i)/float64(segments)
+			points = append(points, NewVertex2D(cx+math.Cos(angle)*r, cy+math.Sin(angle)*r))
+		}
+		return append(points, b)
+	})
+}
+
+// Chamfer cuts corners of the face off straight, distance along each
+// edge from the corner. Corners are chosen and distances limited the
+// same way as Fillet
+func (f *Face2D) Chamfer(distance float64, corners ...interface{}) *Face2D {
+	return f.cutCorners(corners, func(v *Vertex2D, d1, d2 [2]float64, limit, half float64) []*Vertex2D {
+		t := math.Min(distance, limit)
+		return []*Vertex2D{
+			NewVertex2D(v.X+d1[0]*t, v.Y+d1[1]*t),
+			NewVertex2D(v.X+d2[0]*t, v.Y+d2[1]*t),
+		}
+	})
+}
+
+// cutCorners replaces each chosen corner with the points from cut, which
+// is given unit directions along the edges to the previous and next
+// vertices, the furthest it may go along them, and half the corner angle
+func (f *Face2D) cutCorners(corners []interface{}, cut func(v *Vertex2D, d1, d2 [2]float64, limit, half float64) []*Vertex2D) *Face2D {
+	n := len(f.Vertices)
+	chosen := make([]bool, n)
+	for i, v := range f.Vertices {
+		if len(corners) == 0 {
+			chosen[i] = true
+		}
+		for _, corner := range corners {
+			switch c := corner.(type) {
+			case int:
+				chosen[i] = chosen[i] || c == i
+			case string:
+				chosen[i] = chosen[i] || (v.Label != "" && c == v.Label)
+			}
+		}
+	}
+
+	res := &Face2D{PD: f.PD, PercShape: f.PercShape}
+	for i, v := range f.Vertices {
+		prev, next := f.Vertices[(i+n-1)%n], f.Vertices[(i+1)%n]
+		l1, l2 := v.Distance(prev), v.Distance(next)
+		if !chosen[i] || n < 3 || l1 == 0 || l2 == 0 {
+			res.Vertices = append(res.Vertices, v.Copy())
+			continue
+		}
+		d1 := [2]float64{(prev.X - v.X) / l1, (prev.Y - v.Y) / l1}
+		d2 := [2]float64{(next.X - v.X) / l2, (next.Y - v.Y) / l2}
+		half := math.Acos(math.Max(-1, math.Min(1, d1[0]*d2[0]+d1[1]*d2[1]))) / 2
+		if half > math.Pi/2-1e-9 || half < 1e-9 {
+			// straight through or doubling back, there's no corner
+			res.Vertices = append(res.Vertices, v.Copy())
+			continue
+		}
+		// split edges between the corners at both ends
+		if chosen[(i+n-1)%n] {
+			l1 /= 2
+		}
+		if chosen[(i+1)%n] {
+			l2 /= 2
+		}
+		res.Vertices = append(res.Vertices, cut(v, d1, d2, math.Min(l1, l2), half)...)
+	}
+	res.Vertices = cleanRing(res.Vertices, 0)
+	return res
+}
@@ -0,0 +1,45 @@
+package toothpaste
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFillet(t *testing.T) {
+	f := Square(2, 2).Fillet(0.5, 16)
+	if n := len(f.Vertices); n != 4*17 {
+		t.Errorf("Expected %v vertices, got %v", 4*17, n)
+	}
+	if area, expected := f.Area(), 4-(1-math.Pi/4); math.Abs(area-expected) > 5e-3 {
+		t.Errorf("Expected area %v, got %v", expected, area)
+	}
+
+	// too big a radius is clamped to meet in the middle of each edge
+	f = Square(2, 2).Fillet(5, 64)
+	if area := f.Area(); math.Abs(area-math.Pi) > 1e-2 {
+		t.Errorf("Expected a circle, got area %v", area)
+	}
+	if len(f.Vertices) != 4*64 {
+		t.Errorf("Expected the arcs to join without duplicates, got %v vertices", len(f.Vertices))
+	}
+}
+
+func TestChamferCorners(t *testing.T) {
+	square := Square(2, 2)
+	square.Vertices[0].Label = "a"
+	square.Vertices[2].Label = "c"
+
+	f := square.Chamfer(0.5, "a", 1)
+	if n := len(f.Vertices); n != 6 {
+		t.Errorf("Expected 6 vertices, got %v", n)
+	}
+	if area := f.Area(); math.Abs(area-3.75) > 1e-9 {
+		t.Errorf("Expected area 3.75, got %v", area)
+	}
+	if f.Find("a") != nil || f.Find("c") == nil {
+		t.Errorf("Expected only the untouched label to be kept")
+	}
+	if len(square.Vertices) != 4 {
+		t.Errorf("Expected the original face to be unchanged")
+	}
+}
@@ -0,0 +1,159 @@
+package toothpaste
+
+import (
+	"math"
+)
+
+// Curve flattening. Each function returns the points after the start
+// point, ending with the end point, so that consecutive segments can be
+// appended to the same outline
+
+// maxCurveSegments is the most segments a single curve is split into,
+// however small the tolerance (including 0 or less)
+const maxCurveSegments = 1024
+
+// curveSegments rounds n up to a whole number of segments between 1 and
+// maxCurveSegments, which is also used when n isn't a number
+func curveSegments(n float64) int {
+	if math.IsNaN(n) || n > maxCurveSegments {
+		return maxCurveSegments
+	}
+	return int(math.Max(1, math.Ceil(n)))
+}
+
+// quadSegments is how many straight segments keep a quadratic bezier
+// within tolerance of the curve
+func quadSegments(p0, p1, p2 *Vertex2D, tolerance float64) int {
+	dd := math.Hypot(p0.X-2*p1.X+p2.X, p0.Y-2*p1.Y+p2.Y)
+	if dd == 0 {
+		// a straight line
+		return 1
+	}
+	return curveSegments(math.Sqrt(dd / (4 * tolerance)))
+}
+
+// cubicSegments is how many straight segments keep a cubic bezier
+// within tolerance of the curve
+func cubicSegments(p0, p1, p2, p3 *Vertex2D, tolerance float64) int {
+	dd := math.Max(
+		math.Hypot(p0.X-2*p1.X+p2.X, p0.Y-2*p1.Y+p2.Y),
+		math.Hypot(p1.X-2*p2.X+p3.X, p1.Y-2*p2.Y+p3.Y),
+	)
+	if dd == 0 {
+		return 1
+	}
+	return curveSegments(math.Sqrt(3 * dd / (4 * tolerance)))
+}
+
+func flattenQuad(p0, p1, p2 *Vertex2D, segments int) []*Vertex2D {
+	points := make([]*Vertex2D, 0, segments)
+	for i := 1; i <= segments; i++ {
+		t := float64(i) / float64(segments)
+		mt := 1 - t
+		points = append(points, NewVertex2D(
+			mt*mt*p0.X+2*mt*t*p1.X+t*t*p2.X,
+			mt*mt*p0.Y+2*mt*t*p1.Y+t*t*p2.Y,
+		))
+	}
+	return points
+}
+
+func flattenCubic(p0, p1, p2, p3 *Vertex2D, segments int) []*Vertex2D {
+	points := make([]*Vertex2D, 0, segments)
+	for i := 1; i <= segments; i++ {
+		t := float64(i) / float64(segments)
+		mt := 1 - t
+		a, b, c, d := mt*mt*mt, 3*mt*mt*t, 3*mt*t*t, t*t*t
+		points = append(points, NewVertex2D(
+			a*p0.X+b*p1.X+c*p2.X+d*p3.X,
+			a*p0.Y+b*p1.Y+c*p2.Y+d*p3.Y,
+		))
+	}
+	return points
+}
+
+// ellipseArc describes an arc by its center, in the form the svg
+// endpoint parameters are converted to
+type ellipseArc struct {
+	CX, CY     float64
+	RX, RY     float64
+	Rotation   float64 // radians
+	Start      float64 // radians
+	Sweep      float64 // radians, negative for clockwise
+	degenerate bool
+}
+
+// arcSegments is how many straight segments keep the arc within
+// tolerance, based on the sagitta of the largest radius
+func (a *ellipseArc) segments(tolerance float64) int {
+	r := math.Max(a.RX, a.RY)
+	if r <= tolerance {
+		return 1
+	}
+	step := 2 * math.Acos(1-tolerance/r)
+	return curveSegments(math.Abs(a.Sweep) / step)
+}
+
+func (a *ellipseArc) point(angle float64) *Vertex2D {
+	cos, sin := math.Cos(a.Rotation), math.Sin(a.Rotation)
+	x, y := a.RX*math.Cos(angle), a.RY*math.Sin(angle)
+	return NewVertex2D(a.CX+x*cos-y*sin, a.CY+x*sin+y*cos)
+}
+
+func (a *ellipseArc) flatten(segments int) []*Vertex2D {
+	points := make([]*Vertex2D, 0, segments)
+	for i := 1; i <= segments; i++ {
+		points = append(points, a.point(a.Start+a.Sweep*float64(i)/float64(segments)))
+	}
+	return points
+}
+
+// endpointArc converts svg style arc parameters to a center based arc,
+// following the svg implementation notes (F.6.5 and F.6.6)
+func endpointArc(x1, y1, rx, ry, rotation float64, largeArc, sweep bool, x2, y2 float64) *ellipseArc {
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 || (x1 == x2 && y1 == y2) {
+		return &ellipseArc{degenerate: true}
+	}
+	phi := rotation * math.Pi / 180
+	cos, sin := math.Cos(phi), math.Sin(phi)
+	dx, dy := (x1-x2)/2, (y1-y2)/2
+	x1p := cos*dx + sin*dy
+	y1p := -sin*dx + cos*dy
+
+	// scale up radii that are too small to reach the end point
+	lambda := x1p*x1p/(rx*rx) + y1p*y1p/(ry*ry)
+	if lambda > 1 {
+		rx *= math.Sqrt(lambda)
+		ry *= math.Sqrt(lambda)
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1p*y1p - ry*ry*x1p*x1p
+	den := rx*rx*y1p*y1p + ry*ry*x1p*x1p
+	coef := math.Sqrt(math.Max(0, num/den))
+	if largeArc == sweep {
+		coef = -coef
+	}
+	cxp := coef * rx * y1p / ry
+	cyp := -coef * ry * x1p / rx
+
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	start := angle(1, 0, (x1p-cxp)/rx, (y1p-cyp)/ry)
+	delta := angle((x1p-cxp)/rx, (y1p-cyp)/ry, (-x1p-cxp)/rx, (-y1p-cyp)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+	return &ellipseArc{
+		CX:       cos*cxp - sin*cyp + (x1+x2)/2,
+		CY:       sin*cxp + cos*cyp + (y1+y2)/2,
+		RX:       rx,
+		RY:       ry,
+		Rotation: phi,
+		Start:    start,
+		Sweep:    delta,
+	}
+}
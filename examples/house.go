@@ -38,7 +38,7 @@ func main() {
 	// the rest of the house will move with it again too
 	roof = roof.Detach()
 	roof.Mul2D(1.2)
-	roof.ExtrudePoint(h / 2)
+	roof.Roof(40)
 
 	node.Center()
 	node.Generate("house.obj")
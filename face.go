@@ -79,6 +79,61 @@ func (f *Face2D) ContainsExact(v *Vertex2D) bool {
 	return false
 }
 
+type FillRule int
+
+const (
+	EvenOdd FillRule = iota
+	NonZero
+)
+
+// SignedArea is positive when the vertices run counter-clockwise
+func (f *Face2D) SignedArea() float64 {
+	return signedArea(f.Vertices)
+}
+
+func (f *Face2D) Area() float64 {
+	return math.Abs(signedArea(f.Vertices))
+}
+
+func (f *Face2D) IsClockwise() bool {
+	return signedArea(f.Vertices) < 0
+}
+
+// Contains tests whether the point is inside the face, using the
+// even-odd rule unless another is given. The rules only differ for
+// faces that cross themselves
+func (f *Face2D) Contains(v *Vertex2D, rule ...FillRule) bool {
+	if len(rule) > 0 && rule[0] == NonZero {
+		return windingNumber(v.X, v.Y, f.Vertices) != 0
+	}
+	return pointInRing(v.X, v.Y, f.Vertices)
+}
+
+func (f *Face2D) Perimeter() float64 {
+	var perimeter float64
+	for i, vertex := range f.Vertices {
+		perimeter += vertex.Distance(f.Vertices[(i+1)%len(f.Vertices)])
+	}
+	return perimeter
+}
+
+// AreaCentroid is the center of mass of the face, unlike Centroid which
+// averages the vertices and so drifts towards wherever they are dense
+func (f *Face2D) AreaCentroid() *Vertex2D {
+	var x, y, area float64
+	for i, v := range f.Vertices {
+		next := f.Vertices[(i+1)%len(f.Vertices)]
+		cross := v.X*next.Y - next.X*v.Y
+		x += (v.X + next.X) * cross
+		y += (v.Y + next.Y) * cross
+		area += cross
+	}
+	if area == 0 {
+		return f.Centroid()
+	}
+	return NewVertex2D(x/(3*area), y/(3*area))
+}
+
 func (f *Face2D) Flatten() []float64 {
 	flattened := make([]float64, len(f.Vertices)*2)
 	for i, vertex := range f.Vertices {
@@ -267,6 +322,53 @@ func (f *Face2D) Find(label string) *Vertex2D {
 	return nil
 }
 
+// Shape2D is an outer face together with the holes cut out of it.
+// The outer face runs counter-clockwise and the holes clockwise
+type Shape2D struct {
+	Outer *Face2D
+	Holes []*Face2D
+}
+
+func NewShape2D(outer *Face2D, holes ...*Face2D) *Shape2D {
+	return &Shape2D{outer, holes}
+}
+
+func (s *Shape2D) Faces() []*Face2D {
+	faces := []*Face2D{s.Outer}
+	faces = append(faces, s.Holes...)
+	return faces
+}
+
+func (s *Shape2D) Copy() *Shape2D {
+	holes := make([]*Face2D, len(s.Holes))
+	for i, h := range s.Holes {
+		holes[i] = h.Copy()
+	}
+	return NewShape2D(s.Outer.Copy(), holes...)
+}
+
+func (s *Shape2D) Translate(x, y float64) {
+	for _, f := range s.Faces() {
+		f.Translate(x, y)
+	}
+}
+
+func (s *Shape2D) ScaleFixed(x, y float64) {
+	for _, f := range s.Faces() {
+		f.ScaleFixed(x, y)
+	}
+}
+
+// Node creates a node with the outer face and holes of the shape,
+// placed in 3D the same way as Face2D.To3D
+func (s *Shape2D) Node(axis ...Axis) *Node {
+	holes := make([]*Face3D, len(s.Holes))
+	for i, h := range s.Holes {
+		holes[i] = h.ToFixed3D(axis...)
+	}
+	return NewNode(s.Outer.ToFixed3D(axis...), holes...)
+}
+
 // 3D
 type Face3D struct {
 	Vertices  []*Vertex3D
@@ -330,6 +432,43 @@ func (f *Face3D) Centroid() *Vertex3D {
 	return NewVertex3D(x/float64(len(f.Vertices)), y/float64(len(f.Vertices)), z/float64(len(f.Vertices)))
 }
 
+// Area is the area of the face in its own plane
+func (f *Face3D) Area() float64 {
+	sum := NewVertex3D(0, 0, 0)
+	for i, v := range f.Vertices {
+		sum = sum.Add(v.Cross(f.Vertices[(i+1)%len(f.Vertices)]))
+	}
+	return sum.Norm() / 2
+}
+
+// AreaCentroid is the center of mass of the face, see Face2D.AreaCentroid
+func (f *Face3D) AreaCentroid() *Vertex3D {
+	if len(f.Vertices) < 3 {
+		return f.Centroid()
+	}
+	// fan out from the first vertex, with triangles that fold back
+	// against the normal counting negatively
+	normal := f.Normal()
+	first := f.Vertices[0]
+	centroid := NewVertex3D(0, 0, 0)
+	var area float64
+	for i := 1; i < len(f.Vertices)-1; i++ {
+		a, b := f.Vertices[i], f.Vertices[i+1]
+		weight := a.Subtract(first).Cross(b.Subtract(first)).Dot(normal)
+		centroid = centroid.Add(NewVertex3D(
+			(first.X+a.X+b.X)*weight,
+			(first.Y+a.Y+b.Y)*weight,
+			(first.Z+a.Z+b.Z)*weight,
+		))
+		area += weight
+	}
+	if area == 0 {
+		return f.Centroid()
+	}
+	centroid.Mul(1 / (3 * area))
+	return centroid
+}
+
 func (f *Face3D) Translate(x, y, z float64) {
 	for _, vertex := range f.Vertices {
 		vertex.Translate(x, y, z)
@@ -0,0 +1,52 @@
+package toothpaste
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFace2DQueries(t *testing.T) {
+	// an L shape, whose vertex average is not its center of mass
+	l := NewFace2D(0, 0, 2, 0, 2, 1, 1, 1, 1, 2, 0, 2)
+	if area := l.SignedArea(); area != 3 {
+		t.Errorf("Expected signed area 3, got %v", area)
+	}
+	if l.IsClockwise() {
+		t.Errorf("Expected counter-clockwise")
+	}
+	if p := l.Perimeter(); p != 8 {
+		t.Errorf("Expected perimeter 8, got %v", p)
+	}
+	c := l.AreaCentroid()
+	if math.Abs(c.X-5.0/6) > 1e-9 || math.Abs(c.Y-5.0/6) > 1e-9 {
+		t.Errorf("Expected centroid (5/6, 5/6), got %v", c)
+	}
+	if !l.Contains(NewVertex2D(0.5, 1.5)) || l.Contains(NewVertex2D(1.5, 1.5)) {
+		t.Errorf("Expected the point test to follow the L")
+	}
+
+	// a pentagram overlaps itself in the middle
+	star := NewFace2D(0, 1, 0.59, -0.81, -0.95, 0.31, 0.95, 0.31, -0.59, -0.81)
+	center := NewVertex2D(0, 0)
+	if star.Contains(center) || !star.Contains(center, NonZero) {
+		t.Errorf("Expected the center to be outside with even-odd and inside with nonzero")
+	}
+}
+
+func TestFace3DQueries(t *testing.T) {
+	l := NewFace2D(0, 0, 2, 0, 2, 1, 1, 1, 1, 2, 0, 2).To3D()
+	if area := l.Area(); math.Abs(area-3) > 1e-9 {
+		t.Errorf("Expected area 3, got %v", area)
+	}
+	c := l.AreaCentroid()
+	if math.Abs(c.X-5.0/6) > 1e-9 || math.Abs(c.Y) > 1e-9 || math.Abs(c.Z-5.0/6) > 1e-9 {
+		t.Errorf("Expected centroid (5/6, 0, 5/6), got %v", c)
+	}
+
+	cube := testCube()
+	cube.Translate(3, 0, 0)
+	cube.Center(true)
+	if c := cube.Nodes().AreaCentroid(); c.Norm() > 1e-9 {
+		t.Errorf("Expected the cube to be centered, got %v", c)
+	}
+}
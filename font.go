@@ -0,0 +1,192 @@
+package toothpaste
+
+import (
+	"fmt"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"os"
+)
+
+type TextAlign int
+
+const (
+	AlignLeft TextAlign = iota
+	AlignCenter
+	AlignRight
+)
+
+type TextOptions struct {
+	LineSpacing   float64 // multiple of the font's line height, defaults to 1
+	LetterSpacing float64 // extra space added after each glyph
+	Align         TextAlign
+	NoKerning     bool
+}
+
+// Font is a TrueType or OpenType font that text outlines can be made
+// from. It is safe to use from several goroutines at once
+type Font struct {
+	font *sfnt.Font
+}
+
+// Glyph is the outline of a single character, already placed at its
+// position in the text. X and Y are the glyph's origin on the baseline
+type Glyph struct {
+	Rune   rune
+	X, Y   float64
+	Shapes []*Shape2D
+}
+
+func ParseFont(data []byte) (*Font, error) {
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Font{font: f}, nil
+}
+
+func LoadFont(filename string) (*Font, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFont(data)
+}
+
+// Text lays out the string with the baseline of the first line at y=0,
+// size being the height of an em. Each line starts at x=0 (or is
+// centered or right aligned on it), and each newline moves down a line.
+// Curves are flattened to within tolerance. Glyphs without an outline,
+// such as spaces, are left out
+func (f *Font) Text(s string, size, tolerance float64, opts ...TextOptions) ([]*Glyph, error) {
+	if tolerance <= 0 {
+		return nil, fmt.Errorf("font: tolerance must be positive")
+	}
+	var opt TextOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.LineSpacing == 0 {
+		opt.LineSpacing = 1
+	}
+
+	// load everything in font units so no precision is lost to the
+	// 26.6 fixed point format, then scale to size
+	upem := f.font.UnitsPerEm()
+	ppem := fixed.I(int(upem))
+	scale := size / float64(upem)
+	// a buffer per call, so that calls don't share any state
+	var buf sfnt.Buffer
+	metrics, err := f.font.Metrics(&buf, ppem, font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	lineHeight := float64(metrics.Height) / 64 * scale * opt.LineSpacing
+
+	var glyphs, line []*Glyph
+	// width is where the line ends, which leaves out the letter spacing
+	// after the last glyph
+	x, y, width := 0.0, 0.0, 0.0
+	endLine := func() {
+		shift := 0.0
+		switch opt.Align {
+		case AlignCenter:
+			shift = -width / 2
+		case AlignRight:
+			shift = -width
+		}
+		if shift != 0 {
+			for _, g := range line {
+				g.X += shift
+				for _, shape := range g.Shapes {
+					shape.Translate(shift, 0)
+				}
+			}
+		}
+		glyphs = append(glyphs, line...)
+		line = nil
+	}
+
+	var prev sfnt.GlyphIndex
+	hasPrev := false
+	for _, r := range s {
+		if r == '\n' {
+			endLine()
+			x, y, width = 0, y-lineHeight, 0
+			hasPrev = false
+			continue
+		}
+		index, err := f.font.GlyphIndex(&buf, r)
+		if err != nil {
+			return nil, err
+		}
+		if hasPrev && !opt.NoKerning {
+			// fonts without a kern table return an error, which just
+			// means there is nothing to adjust
+			if kern, err := f.font.Kern(&buf, prev, index, ppem, font.HintingNone); err == nil {
+				x += float64(kern) / 64 * scale
+			}
+		}
+		shapes, err := f.glyphShapes(&buf, index, ppem, scale, x, y, tolerance)
+		if err != nil {
+			return nil, err
+		}
+		if len(shapes) > 0 {
+			line = append(line, &Glyph{Rune: r, X: x, Y: y, Shapes: shapes})
+		}
+		advance, err := f.font.GlyphAdvance(&buf, index, ppem, font.HintingNone)
+		if err != nil {
+			return nil, err
+		}
+		width = x + float64(advance)/64*scale
+		x = width + opt.LetterSpacing
+		prev, hasPrev = index, true
+	}
+	endLine()
+	return glyphs, nil
+}
+
+// glyphShapes flattens the glyph's contours and sorts them into outers
+// and counters. TrueType and CFF outlines wind in opposite directions,
+// so nesting is used rather than orientation
+func (f *Font) glyphShapes(buf *sfnt.Buffer, index sfnt.GlyphIndex, ppem fixed.Int26_6, scale, x, y, tolerance float64) ([]*Shape2D, error) {
+	segments, err := f.font.LoadGlyph(buf, index, ppem, nil)
+	if err != nil {
+		return nil, err
+	}
+	// sfnt has y pointing down
+	point := func(p fixed.Point26_6) *Vertex2D {
+		return NewVertex2D(x+float64(p.X)/64*scale, y-float64(p.Y)/64*scale)
+	}
+
+	var rings []*Face2D
+	var ring []*Vertex2D
+	var cur *Vertex2D
+	finish := func() {
+		if ring = cleanRing(ring, 0); len(ring) >= 3 {
+			rings = append(rings, &Face2D{Vertices: ring})
+		}
+		ring = nil
+	}
+	for _, seg := range segments {
+		switch seg.Op {
+		case sfnt.SegmentOpMoveTo:
+			finish()
+			cur = point(seg.Args[0])
+			ring = append(ring, cur)
+		case sfnt.SegmentOpLineTo:
+			cur = point(seg.Args[0])
+			ring = append(ring, cur)
+		case sfnt.SegmentOpQuadTo:
+			c, end := point(seg.Args[0]), point(seg.Args[1])
+			ring = append(ring, flattenQuad(cur, c, end, quadSegments(cur, c, end, tolerance))...)
+			cur = end
+		case sfnt.SegmentOpCubeTo:
+			c1, c2, end := point(seg.Args[0]), point(seg.Args[1]), point(seg.Args[2])
+			ring = append(ring, flattenCubic(cur, c1, c2, end, cubicSegments(cur, c1, c2, end, tolerance))...)
+			cur = end
+		}
+	}
+	finish()
+	return shapesFromRings(rings), nil
+}
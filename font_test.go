@@ -0,0 +1,57 @@
+package toothpaste
+
+import (
+	"golang.org/x/image/font/gofont/goregular"
+	"math"
+	"testing"
+)
+
+func TestFontText(t *testing.T) {
+	f, err := ParseFont(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	glyphs, err := f.Text("oB l\nA", 10, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(glyphs) != 4 {
+		t.Fatalf("Expected 4 glyphs without the space, got %v", len(glyphs))
+	}
+	holes := map[rune]int{'o': 1, 'B': 2, 'l': 0, 'A': 1}
+	for _, g := range glyphs {
+		if len(g.Shapes) != 1 {
+			t.Errorf("Expected %q to be 1 shape, got %v", g.Rune, len(g.Shapes))
+			continue
+		}
+		if len(g.Shapes[0].Holes) != holes[g.Rune] {
+			t.Errorf("Expected %q to have %v holes, got %v", g.Rune, holes[g.Rune], len(g.Shapes[0].Holes))
+		}
+		if signedArea(g.Shapes[0].Outer.Vertices) <= 0 {
+			t.Errorf("Expected %q outer to be counter-clockwise", g.Rune)
+		}
+	}
+	if glyphs[1].X <= glyphs[0].X || glyphs[0].Y != 0 {
+		t.Errorf("Expected glyphs to advance along the baseline")
+	}
+	if glyphs[3].X != 0 || glyphs[3].Y >= 0 {
+		t.Errorf("Expected the second line to start below the first, got %v, %v", glyphs[3].X, glyphs[3].Y)
+	}
+
+	centered, err := f.Text("oo", 10, 0.01, TextOptions{Align: AlignCenter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if centered[0].X >= 0 || math.Abs(centered[1].X) > 1e-9 {
+		t.Errorf("Expected the middle of the text at x=0, got %v and %v", centered[0].X, centered[1].X)
+	}
+
+	// the spacing after the last glyph doesn't count towards the width
+	spaced, err := f.Text("oo", 10, 0.01, TextOptions{Align: AlignCenter, LetterSpacing: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(spaced[0].X-(centered[0].X-1)) > 1e-9 || math.Abs(spaced[1].X-1) > 1e-9 {
+		t.Errorf("Expected the spaced text to stay centered, got %v and %v", spaced[0].X, spaced[1].X)
+	}
+}
@@ -0,0 +1,460 @@
+package toothpaste
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// glTF 2.0 document, only the parts that are needed to describe
+// a single mesh with materials and textures
+type gltfDocument struct {
+	Asset       gltfAsset        `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes"`
+	Materials   []gltfMaterial   `json:"materials,omitempty"`
+	Textures    []gltfTexture    `json:"textures,omitempty"`
+	Images      []gltfImage      `json:"images,omitempty"`
+	Samplers    []gltfSampler    `json:"samplers,omitempty"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []gltfBuffer     `json:"buffers"`
+}
+
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator,omitempty"`
+}
+
+type gltfScene struct {
+	Nodes []int `json:"nodes"`
+}
+
+type gltfNode struct {
+	Mesh int `json:"mesh"`
+}
+
+type gltfMesh struct {
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int         `json:"attributes"`
+	Indices    int                    `json:"indices"`
+	Material   int                    `json:"material"`
+	Mode       int                    `json:"mode"`
+	Extras     map[string]interface{} `json:"extras,omitempty"`
+}
+
+type gltfMaterial struct {
+	Name                 string               `json:"name"`
+	PBRMetallicRoughness gltfPBRMetallicRough `json:"pbrMetallicRoughness"`
+}
+
+type gltfPBRMetallicRough struct {
+	BaseColorFactor  [4]float64       `json:"baseColorFactor"`
+	BaseColorTexture *gltfTextureInfo `json:"baseColorTexture,omitempty"`
+	MetallicFactor   float64          `json:"metallicFactor"`
+	RoughnessFactor  float64          `json:"roughnessFactor"`
+}
+
+type gltfTextureInfo struct {
+	Index int `json:"index"`
+}
+
+type gltfTexture struct {
+	Sampler int `json:"sampler"`
+	Source  int `json:"source"`
+}
+
+type gltfImage struct {
+	URI        string `json:"uri,omitempty"`
+	MimeType   string `json:"mimeType,omitempty"`
+	BufferView *int   `json:"bufferView,omitempty"`
+}
+
+type gltfSampler struct {
+	MagFilter int `json:"magFilter"`
+	MinFilter int `json:"minFilter"`
+	WrapS     int `json:"wrapS"`
+	WrapT     int `json:"wrapT"`
+}
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float64 `json:"min,omitempty"`
+	Max           []float64 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target,omitempty"`
+}
+
+type gltfBuffer struct {
+	URI        string `json:"uri,omitempty"`
+	ByteLength int    `json:"byteLength"`
+}
+
+const (
+	gltfFloat         = 5126
+	gltfUnsignedInt   = 5125
+	gltfArrayBuffer   = 34962
+	gltfElementBuffer = 34963
+	gltfTriangles     = 4
+	gltfLinear        = 9729
+	gltfLinearMipmap  = 9987
+	gltfRepeat        = 10497
+)
+
+// gltfPrimitiveData holds the geometry of every node sharing a tag
+// and Meta
+type gltfPrimitiveData struct {
+	Tag          string
+	ImageTexture bool
+	Positions    []float32
+	Normals      []float32
+	UVs          []float32
+	Indices      []uint32
+	Meta         map[string]interface{}
+	lookup       map[[8]float64]uint32
+}
+
+func (p *gltfPrimitiveData) add(v, normal *Vertex3D) {
+	// glTF puts the uv origin at the top left
+	key := [8]float64{v.X, v.Y, v.Z, normal.X, normal.Y, normal.Z, v.U, 1 - v.V}
+	index, seen := p.lookup[key]
+	if !seen {
+		index = uint32(len(p.Positions) / 3)
+		p.lookup[key] = index
+		p.Positions = append(p.Positions, float32(v.X), float32(v.Y), float32(v.Z))
+		p.Normals = append(p.Normals, float32(normal.X), float32(normal.Y), float32(normal.Z))
+		p.UVs = append(p.UVs, float32(v.U), float32(1-v.V))
+	}
+	p.Indices = append(p.Indices, index)
+}
+
+// gltfPrimitives groups the triangles of the chain by tag, in the
+// order the tags are first seen. Nodes with the same tag but different
+// Meta are kept in separate primitives, so each keeps its own extras
+func (n *Node) gltfPrimitives() []*gltfPrimitiveData {
+	nodes := n.Nodes()
+	var primitives []*gltfPrimitiveData
+	byKey := make(map[string]*gltfPrimitiveData)
+	for i, face := range nodes.orientedTriangles() {
+		if len(face) == 0 {
+			// nothing to draw, and an empty accessor has no bounds
+			continue
+		}
+		node := nodes[i]
+		tag := node.Tag
+		if tag == "" {
+			tag = "default"
+		}
+		var meta map[string]interface{}
+		for key, value := range node.Meta {
+			// skip anything that can't be represented in json
+			if _, err := json.Marshal(value); err != nil {
+				continue
+			}
+			if meta == nil {
+				meta = map[string]interface{}{}
+			}
+			meta[key] = value
+		}
+		// maps are marshalled with sorted keys, so equal Meta gives equal keys
+		encoded, _ := json.Marshal(meta)
+		key := tag + "\x00" + string(encoded)
+		p, ok := byKey[key]
+		if !ok {
+			p = &gltfPrimitiveData{Tag: tag, Meta: meta, lookup: map[[8]float64]uint32{}}
+			byKey[key] = p
+			primitives = append(primitives, p)
+		}
+		p.ImageTexture = p.ImageTexture || node.ImageTexture
+		normal := node.Outer.Normal()
+		for _, tri := range face {
+			for _, v := range tri {
+				p.add(v, normal)
+			}
+		}
+	}
+	return primitives
+}
+
+// gltfBuilder accumulates the binary buffer while the document is built
+type gltfBuilder struct {
+	doc gltfDocument
+	bin bytes.Buffer
+}
+
+func (b *gltfBuilder) addView(data []byte, target int) int {
+	// every view starts on a 4 byte boundary
+	for b.bin.Len()%4 != 0 {
+		b.bin.WriteByte(0)
+	}
+	b.doc.BufferViews = append(b.doc.BufferViews, gltfBufferView{
+		Buffer:     0,
+		ByteOffset: b.bin.Len(),
+		ByteLength: len(data),
+		Target:     target,
+	})
+	b.bin.Write(data)
+	return len(b.doc.BufferViews) - 1
+}
+
+func (b *gltfBuilder) addFloats(values []float32, components int, typ string, bounds bool) int {
+	data := make([]byte, len(values)*4)
+	for i, value := range values {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(value))
+	}
+	accessor := gltfAccessor{
+		BufferView:    b.addView(data, gltfArrayBuffer),
+		ComponentType: gltfFloat,
+		Count:         len(values) / components,
+		Type:          typ,
+	}
+	if bounds {
+		accessor.Min = make([]float64, components)
+		accessor.Max = make([]float64, components)
+		for i := 0; i < components; i++ {
+			accessor.Min[i] = math.Inf(1)
+			accessor.Max[i] = math.Inf(-1)
+		}
+		for i, value := range values {
+			accessor.Min[i%components] = math.Min(accessor.Min[i%components], float64(value))
+			accessor.Max[i%components] = math.Max(accessor.Max[i%components], float64(value))
+		}
+	}
+	b.doc.Accessors = append(b.doc.Accessors, accessor)
+	return len(b.doc.Accessors) - 1
+}
+
+func (b *gltfBuilder) addIndices(values []uint32) int {
+	data := make([]byte, len(values)*4)
+	for i, value := range values {
+		binary.LittleEndian.PutUint32(data[i*4:], value)
+	}
+	b.doc.Accessors = append(b.doc.Accessors, gltfAccessor{
+		BufferView:    b.addView(data, gltfElementBuffer),
+		ComponentType: gltfUnsignedInt,
+		Count:         len(values),
+		Type:          "SCALAR",
+	})
+	return len(b.doc.Accessors) - 1
+}
+
+func (b *gltfBuilder) addImage(path string, embed bool) (int, error) {
+	image := gltfImage{}
+	if embed {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return 0, err
+		}
+		view := b.addView(data, 0)
+		image.BufferView = &view
+		image.MimeType = imageMimeType(path)
+	} else {
+		image.URI = filepath.ToSlash(path)
+	}
+	if len(b.doc.Samplers) == 0 {
+		b.doc.Samplers = append(b.doc.Samplers, gltfSampler{
+			MagFilter: gltfLinear,
+			MinFilter: gltfLinearMipmap,
+			WrapS:     gltfRepeat,
+			WrapT:     gltfRepeat,
+		})
+	}
+	b.doc.Images = append(b.doc.Images, image)
+	b.doc.Textures = append(b.doc.Textures, gltfTexture{Sampler: 0, Source: len(b.doc.Images) - 1})
+	return len(b.doc.Textures) - 1, nil
+}
+
+func imageMimeType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	default:
+		return "image/png"
+	}
+}
+
+// buildGLTF creates the document and its binary buffer. When embed is
+// true, image textures are copied into the buffer instead of referenced
+func (n *Node) buildGLTF(embed bool, colors map[string][3]float64) (*gltfBuilder, error) {
+	b := &gltfBuilder{}
+	b.doc.Asset = gltfAsset{Version: "2.0", Generator: "toothpaste"}
+	b.doc.Scenes = []gltfScene{{Nodes: []int{0}}}
+	b.doc.Nodes = []gltfNode{{Mesh: 0}}
+
+	mesh := gltfMesh{}
+	primitives := n.gltfPrimitives()
+	// primitives split by Meta still share their tag's material
+	materials := map[string]int{}
+	textured := map[string]bool{}
+	for _, p := range primitives {
+		textured[p.Tag] = textured[p.Tag] || p.ImageTexture
+	}
+	for _, p := range primitives {
+		if _, ok := materials[p.Tag]; !ok {
+			material := gltfMaterial{
+				Name: p.Tag,
+				PBRMetallicRoughness: gltfPBRMetallicRough{
+					BaseColorFactor: [4]float64{1, 1, 1, 1},
+					MetallicFactor:  0,
+					RoughnessFactor: 1,
+				},
+			}
+			if textured[p.Tag] {
+				texture, err := b.addImage(p.Tag, embed)
+				if err != nil {
+					return nil, err
+				}
+				material.PBRMetallicRoughness.BaseColorTexture = &gltfTextureInfo{Index: texture}
+			} else if color, ok := colors[p.Tag]; ok {
+				material.PBRMetallicRoughness.BaseColorFactor = [4]float64{color[0], color[1], color[2], 1}
+			}
+			b.doc.Materials = append(b.doc.Materials, material)
+			materials[p.Tag] = len(b.doc.Materials) - 1
+		}
+
+		primitive := gltfPrimitive{
+			Attributes: map[string]int{
+				"POSITION":   b.addFloats(p.Positions, 3, "VEC3", true),
+				"NORMAL":     b.addFloats(p.Normals, 3, "VEC3", false),
+				"TEXCOORD_0": b.addFloats(p.UVs, 2, "VEC2", false),
+			},
+			Indices:  b.addIndices(p.Indices),
+			Material: materials[p.Tag],
+			Mode:     gltfTriangles,
+			Extras:   p.Meta,
+		}
+		mesh.Primitives = append(mesh.Primitives, primitive)
+	}
+	if len(mesh.Primitives) == 0 {
+		return nil, fmt.Errorf("no triangles to export")
+	}
+	b.doc.Meshes = []gltfMesh{mesh}
+
+	for b.bin.Len()%4 != 0 {
+		b.bin.WriteByte(0)
+	}
+	b.doc.Buffers = []gltfBuffer{{ByteLength: b.bin.Len()}}
+	return b, nil
+}
+
+// WriteGLTF writes the node chain as a .gltf document to w and its
+// geometry to bin. binURI is how the document refers to bin, usually
+// the filename of the .bin file relative to the document
+func (n *Node) WriteGLTF(w, bin io.Writer, binURI string, colors ...map[string][3]float64) error {
+	_colors := map[string][3]float64{}
+	if len(colors) > 0 {
+		_colors = colors[0]
+	}
+	b, err := n.buildGLTF(false, _colors)
+	if err != nil {
+		return err
+	}
+	b.doc.Buffers[0].URI = binURI
+	data, err := json.MarshalIndent(b.doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	_, err = bin.Write(b.bin.Bytes())
+	return err
+}
+
+// WriteGLB writes the node chain as a single binary glTF to w,
+// with any image textures embedded
+func (n *Node) WriteGLB(w io.Writer, colors ...map[string][3]float64) error {
+	_colors := map[string][3]float64{}
+	if len(colors) > 0 {
+		_colors = colors[0]
+	}
+	b, err := n.buildGLTF(true, _colors)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(b.doc)
+	if err != nil {
+		return err
+	}
+	// the json chunk is padded with spaces
+	for len(data)%4 != 0 {
+		data = append(data, ' ')
+	}
+	total := 12 + 8 + len(data) + 8 + b.bin.Len()
+	header := []uint32{
+		0x46546C67, // "glTF"
+		2,
+		uint32(total),
+		uint32(len(data)),
+		0x4E4F534A, // "JSON"
+	}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	chunk := []uint32{uint32(b.bin.Len()), 0x004E4942} // "BIN"
+	if err := binary.Write(w, binary.LittleEndian, chunk); err != nil {
+		return err
+	}
+	_, err = w.Write(b.bin.Bytes())
+	return err
+}
+
+// GenerateGLTF writes the node chain to a .gltf file, with the
+// geometry next to it in a .bin file of the same name
+func (n *Node) GenerateGLTF(filename string, colors ...map[string][3]float64) error {
+	binFilename := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".bin"
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	bin, err := os.Create(binFilename)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := n.WriteGLTF(f, bin, filepath.Base(binFilename), colors...); err != nil {
+		bin.Close()
+		f.Close()
+		return err
+	}
+	if err := bin.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// GenerateGLB writes the node chain to a single .glb file
+func (n *Node) GenerateGLB(filename string, colors ...map[string][3]float64) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := n.WriteGLB(f, colors...); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,106 @@
+package toothpaste
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteGLB(t *testing.T) {
+	node := testCube()
+	node.TagAll("red")
+	node.Last().Tag = "blue"
+	node.Last().SetMeta("weight", 2.5)
+
+	var buf bytes.Buffer
+	err := node.WriteGLB(&buf, map[string][3]float64{
+		"red": {1, 0, 0},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if string(data[:4]) != "glTF" {
+		t.Fatalf("Expected glTF magic, got %q", data[:4])
+	}
+	if total := binary.LittleEndian.Uint32(data[8:12]); int(total) != len(data) {
+		t.Errorf("Expected length %v, got %v", len(data), total)
+	}
+	jsonLength := binary.LittleEndian.Uint32(data[12:16])
+	var doc gltfDocument
+	if err := json.Unmarshal(data[20:20+jsonLength], &doc); err != nil {
+		t.Fatal(err)
+	}
+	primitives := doc.Meshes[0].Primitives
+	if len(primitives) != 2 {
+		t.Fatalf("Expected 2 primitives, got %v", len(primitives))
+	}
+	if doc.Materials[0].Name != "red" || doc.Materials[0].PBRMetallicRoughness.BaseColorFactor != [4]float64{1, 0, 0, 1} {
+		t.Errorf("Expected red material, got %+v", doc.Materials[0])
+	}
+	if primitives[1].Extras["weight"] != 2.5 {
+		t.Errorf("Expected weight in extras, got %v", primitives[1].Extras)
+	}
+	if count := doc.Accessors[primitives[0].Indices].Count; count != 30 {
+		t.Errorf("Expected 30 indices for the red primitive, got %v", count)
+	}
+	binLength := binary.LittleEndian.Uint32(data[20+jsonLength:])
+	if int(binLength) != doc.Buffers[0].ByteLength {
+		t.Errorf("Expected bin chunk of %v bytes, got %v", doc.Buffers[0].ByteLength, binLength)
+	}
+}
+
+func TestWriteGLTF(t *testing.T) {
+	var gltf, bin bytes.Buffer
+	if err := testCube().WriteGLTF(&gltf, &bin, "cube.bin"); err != nil {
+		t.Fatal(err)
+	}
+	var doc gltfDocument
+	if err := json.Unmarshal(gltf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Buffers[0].URI != "cube.bin" || doc.Buffers[0].ByteLength != bin.Len() {
+		t.Errorf("Expected buffer cube.bin of %v bytes, got %+v", bin.Len(), doc.Buffers[0])
+	}
+	position := doc.Accessors[doc.Meshes[0].Primitives[0].Attributes["POSITION"]]
+	if position.Max[1] != 1 || position.Min[1] != 0 {
+		t.Errorf("Expected y bounds of 0 and 1, got %v and %v", position.Min, position.Max)
+	}
+}
+
+func TestGLTFMetaPerNode(t *testing.T) {
+	node := testCube()
+	node.TagAll("wall")
+	nodes := node.Nodes()
+	nodes[0].SetMeta("id", 1)
+	nodes[1].SetMeta("id", 2)
+
+	primitives := node.gltfPrimitives()
+	if len(primitives) != 3 {
+		t.Fatalf("Expected 3 primitives, got %v", len(primitives))
+	}
+	if primitives[0].Meta["id"] != 1 || primitives[1].Meta["id"] != 2 || primitives[2].Meta != nil {
+		t.Errorf("Expected each node to keep its own Meta, got %v, %v and %v", primitives[0].Meta, primitives[1].Meta, primitives[2].Meta)
+	}
+	b, err := node.buildGLTF(false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.doc.Materials) != 1 {
+		t.Errorf("Expected the primitives to share 1 material, got %v", len(b.doc.Materials))
+	}
+}
+
+func TestGLTFSkipsEmptyNodes(t *testing.T) {
+	node := testCube()
+	empty := NewTaggedNode("empty", &Face3D{Vertices: []*Vertex3D{NewVertex3D(0, 0, 0), NewVertex3D(1, 0, 0)}})
+	node.Last().InsertAfter(empty)
+	if primitives := node.gltfPrimitives(); len(primitives) != 1 {
+		t.Errorf("Expected only the cube's primitive, got %v", len(primitives))
+	}
+	var buf bytes.Buffer
+	if err := node.WriteGLB(&buf); err != nil {
+		t.Errorf("Expected a node without triangles to be skipped, got %v", err)
+	}
+}
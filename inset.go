@@ -0,0 +1,249 @@
+package toothpaste
+
+import (
+	"math"
+)
+
+type InsetMode int
+
+const (
+	InsetIndividual InsetMode = iota // every node is inset on its own
+	InsetRegion                      // only the outline of the nodes together is inset
+)
+
+// Inset shrinks the node's outline (and grows its holes) by distance in
+// its own plane, keeping the edges parallel. The band left between the
+// old and new outlines is filled with a quad for each edge, added after
+// the node and tagged tags[i] for edge i of the outer and then the
+// holes. Edges of zero length, from a repeated vertex, are still
+// counted but get no quad. The node itself becomes the inset face, and
+// is returned, ready to be extruded. The inset can only go as far as
+// the first event of the face's straight skeleton, where an edge
+// shrinks away or a concave corner runs into another edge; past that
+// the node is left as it is and nil is returned
+func (n *Node) Inset(distance float64, tags ...string) *Node {
+	plane := newFacePlane(n.Outer.Vertices)
+	if distance > 0 && distance >= insetLimit(n, plane)*(1-1e-9) {
+		return nil
+	}
+	normal := plane.normal
+	var quads []*Node
+	var faces []*Face3D
+	edge := 0
+	for k, f := range n.Faces() {
+		ring, edges := cleanRing3D(f.Vertices)
+		count := len(ring)
+		inward := insetNormals(ring, normal, k == 0)
+		inner := &Face3D{PercShape: f.PercShape}
+		for i, v := range ring {
+			inner.Vertices = append(inner.Vertices, insetVertex(v, inward[(i+count-1)%count], inward[i], distance))
+		}
+		for i := range ring {
+			j := (i + 1) % count
+			quad := &Face3D{Vertices: []*Vertex3D{ring[i], ring[j], inner.Vertices[j], inner.Vertices[i]}}
+			if quadFacesAway(quad, normal) {
+				quad.Flip()
+			}
+			quads = append(quads, NewTaggedNode(getTag(edge+edges[i], tags), quad))
+		}
+		edge += len(f.Vertices)
+		faces = append(faces, inner)
+	}
+	n.Outer, n.Inner = faces[0], faces[1:]
+	if len(quads) > 0 {
+		n.InsertAfter(NewLinkedNodes(quads...).First())
+	}
+	return n
+}
+
+// Inset insets each node on its own, or with InsetRegion, treats the
+// nodes as one surface and only insets where it meets the rest of the
+// model: edges shared between the nodes stay where they are, and the
+// vertices on the outline are moved in. Either way the nodes become the
+// inset faces and are returned, with the quads around them added after
+// the node each edge came from. Nodes that can't be inset that far on
+// their own are left out
+func (ns Nodes) Inset(distance float64, mode InsetMode, tags ...string) Nodes {
+	if mode == InsetIndividual {
+		var nodes Nodes
+		for _, node := range ns {
+			if inset := node.Inset(distance, tags...); inset != nil {
+				nodes = append(nodes, inset)
+			}
+		}
+		return nodes
+	}
+
+	// vertices are matched by position, since nodes that weren't made
+	// together don't share them
+	var unique []*Vertex3D
+	tolerance := 0.0
+	for _, node := range ns {
+		for _, f := range node.Faces() {
+			for _, v := range f.Vertices {
+				tolerance = math.Max(tolerance, math.Max(math.Abs(v.X), math.Max(math.Abs(v.Y), math.Abs(v.Z)))*1e-9)
+			}
+		}
+	}
+	id := func(v *Vertex3D) int {
+		for i, u := range unique {
+			if u == v || u.Distance(v) <= tolerance {
+				return i
+			}
+		}
+		unique = append(unique, v)
+		return len(unique) - 1
+	}
+
+	type regionEdge struct {
+		node     *Node
+		from, to int
+		inward   *Vertex3D
+		normal   *Vertex3D
+	}
+	var edges []*regionEdge
+	used := map[[2]int]bool{}
+	for _, node := range ns {
+		normal := newFacePlane(node.Outer.Vertices).normal
+		for k, f := range node.Faces() {
+			inward := insetNormals(f.Vertices, normal, k == 0)
+			for i, v := range f.Vertices {
+				from, to := id(v), id(f.Vertices[(i+1)%len(f.Vertices)])
+				used[[2]int{from, to}] = true
+				edges = append(edges, &regionEdge{node, from, to, inward[i], normal})
+			}
+		}
+	}
+
+	// the outline is every edge that isn't also used the other way round,
+	// and each vertex on it moves in between the edges either side
+	var boundary []*regionEdge
+	in, out := map[int]*regionEdge{}, map[int]*regionEdge{}
+	for _, e := range edges {
+		if used[[2]int{e.to, e.from}] || e.from == e.to {
+			continue
+		}
+		boundary = append(boundary, e)
+		if out[e.from] == nil {
+			out[e.from] = e
+		}
+		if in[e.to] == nil {
+			in[e.to] = e
+		}
+	}
+	moved := map[int]*Vertex3D{}
+	for i, e := range out {
+		before := e
+		if in[i] != nil {
+			before = in[i]
+		}
+		moved[i] = insetVertex(unique[i], before.inward, e.inward, distance)
+	}
+	for i, e := range in {
+		if moved[i] == nil {
+			moved[i] = insetVertex(unique[i], e.inward, e.inward, distance)
+		}
+	}
+
+	// quads go in after the node their edge came from
+	quads := map[*Node][]*Node{}
+	for i, e := range boundary {
+		quad := &Face3D{Vertices: []*Vertex3D{unique[e.from], unique[e.to], moved[e.to], moved[e.from]}}
+		if quadFacesAway(quad, e.normal) {
+			quad.Flip()
+		}
+		quads[e.node] = append(quads[e.node], NewTaggedNode(getTag(i, tags), quad))
+	}
+	for _, node := range ns {
+		for _, f := range node.Faces() {
+			for i, v := range f.Vertices {
+				if w, ok := moved[id(v)]; ok {
+					f.Vertices[i] = w
+				}
+			}
+		}
+		if len(quads[node]) > 0 {
+			node.InsertAfter(NewLinkedNodes(quads[node]...).First())
+		}
+	}
+	return ns
+}
+
+// insetLimit returns how far the node's outline can move in before it
+// changes shape, which is the height of the lowest node of its straight
+// skeleton that isn't on the outline
+func insetLimit(n *Node, plane *facePlane) float64 {
+	var faces []*Face2D
+	for _, f := range n.Faces() {
+		faces = append(faces, &Face2D{Vertices: plane.project(f.Vertices)})
+	}
+	skeleton := NewShape2D(faces[0], faces[1:]...).StraightSkeleton()
+	limit := math.Inf(1)
+	for _, height := range skeleton.Heights {
+		if height > 0 {
+			limit = math.Min(limit, height)
+		}
+	}
+	return limit
+}
+
+// cleanRing3D drops the vertices of a ring that are in the same place as
+// the next one, so that every edge has a direction, and returns what is
+// left along with the index of each one in the original ring
+func cleanRing3D(vertices []*Vertex3D) ([]*Vertex3D, []int) {
+	tolerance := 0.0
+	for _, v := range vertices {
+		tolerance = math.Max(tolerance, math.Max(math.Abs(v.X), math.Max(math.Abs(v.Y), math.Abs(v.Z)))*1e-9)
+	}
+	var ring []*Vertex3D
+	var indices []int
+	for i, v := range vertices {
+		if v.Distance(vertices[(i+1)%len(vertices)]) > tolerance {
+			ring = append(ring, v)
+			indices = append(indices, i)
+		}
+	}
+	return ring, indices
+}
+
+// insetNormals returns, for each edge of a ring, the unit direction in
+// the face's plane that points away from the edge into the face
+func insetNormals(vertices []*Vertex3D, normal *Vertex3D, outer bool) []*Vertex3D {
+	// rings going anticlockwise round the normal have the face on their
+	// left, unless they are holes
+	left := newFacePlane(vertices).normal.Dot(normal) > 0
+	if !outer {
+		left = !left
+	}
+	res := make([]*Vertex3D, len(vertices))
+	for i, v := range vertices {
+		d := vertices[(i+1)%len(vertices)].Subtract(v)
+		m := normal.Cross(d)
+		if !left {
+			m = m.Negate()
+		}
+		if m.Norm() > 0 {
+			m = m.Normalize()
+		}
+		res[i] = m
+	}
+	return res
+}
+
+// insetVertex moves v by distance away from both edges, whose inward
+// directions are m1 and m2, so that it stays the same distance from each
+func insetVertex(v, m1, m2 *Vertex3D, distance float64) *Vertex3D {
+	dot := m1.Dot(m2)
+	move, k := m1.Add(m2), distance/(1+dot)
+	if 1+dot < 1e-9 {
+		// the edges double back, so just move off the first
+		move, k = m1, distance
+	}
+	return NewLabelledVertex3DWithUV(v.X+move.X*k, v.Y+move.Y*k, v.Z+move.Z*k, v.U, v.V, v.Label)
+}
+
+// quadFacesAway reports whether the quad's normal points the opposite
+// way to the face it was made from
+func quadFacesAway(quad *Face3D, normal *Vertex3D) bool {
+	return newFacePlane(quad.Vertices).normal.Dot(normal) < 0
+}
@@ -0,0 +1,135 @@
+package toothpaste
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNodeInset(t *testing.T) {
+	n := NewNode(Square(2, 2).To3D())
+	normal := n.Outer.Normal()
+	inner := n.Inset(0.5, "front", "right")
+	if inner != n {
+		t.Errorf("Expected the node to become the inset face")
+	}
+	if area := inner.Outer.Area(); math.Abs(area-1) > 1e-9 {
+		t.Errorf("Expected area 1, got %v", area)
+	}
+	nodes := n.Nodes()
+	if len(nodes) != 5 {
+		t.Fatalf("Expected 5 nodes, got %v", len(nodes))
+	}
+	total := 0.0
+	for _, node := range nodes {
+		total += node.Outer.Area()
+		if node.Outer.Normal().Dot(normal) < 0.99 {
+			t.Errorf("Expected every face to face the same way, got %v", node.Outer.Normal())
+		}
+	}
+	if math.Abs(total-4) > 1e-9 {
+		t.Errorf("Expected the faces to still cover 4, got %v", total)
+	}
+	if n.Next.Tag != "front" || n.Next.Next.Tag != "right" {
+		t.Errorf("Expected the quads to be tagged by edge")
+	}
+
+	// concave faces keep their walls even, unlike Mul2D
+	l := NewNode(NewFace2D(0, 0, 2, 0, 2, 1, 1, 1, 1, 2, 0, 2).To3D())
+	if area := l.Inset(0.25).Outer.Area(); math.Abs(area-1.25) > 1e-9 {
+		t.Errorf("Expected area 1.25, got %v", area)
+	}
+
+	// holes grow
+	h := NewShape2D(Square(4, 4), NewFace2D(1, 1, 3, 1, 3, 3, 1, 3)).Node()
+	h.Inset(0.25)
+	if area := h.Outer.Area() - h.Inner[0].Area(); math.Abs(area-6) > 1e-9 {
+		t.Errorf("Expected area 6, got %v", area)
+	}
+	if count := len(h.Nodes()); count != 9 {
+		t.Errorf("Expected 9 nodes, got %v", count)
+	}
+}
+
+func TestNodeInsetLimit(t *testing.T) {
+	// the reflex corners of a U would run into the opposite sides
+	u := NewNode(NewFace2D(0, 0, 3, 0, 3, 3, 2, 3, 2, 1, 1, 1, 1, 3, 0, 3).To3D())
+	if u.Inset(0.6) != nil {
+		t.Errorf("Expected nil when insetting past the skeleton")
+	}
+	if count := len(u.Nodes()); count != 1 {
+		t.Errorf("Expected no quads to be added, got %v nodes", count)
+	}
+	if area := u.Outer.Area(); math.Abs(area-7) > 1e-9 {
+		t.Errorf("Expected the face to be left as it was, got area %v", area)
+	}
+	if u.Inset(0.4) == nil {
+		t.Fatalf("Expected an inset short of the skeleton to work")
+	}
+	if err := u.Outer.Validate(); err != nil {
+		t.Errorf("Expected a valid inset face, got %v", err)
+	}
+
+	// a square collapses to a point at its inradius
+	if NewNode(Square(2, 2).To3D()).Inset(1) != nil {
+		t.Errorf("Expected nil when the inset collapses")
+	}
+	ns := Nodes{NewNode(Square(1, 1).To3D()), NewNode(Square(4, 4).To3D())}
+	if inset := ns.Inset(1, InsetIndividual); len(inset) != 1 || inset[0] != ns[1] {
+		t.Errorf("Expected only the larger square to be inset, got %v", inset)
+	}
+}
+
+func TestNodeInsetDuplicate(t *testing.T) {
+	n := NewNode(NewFace2D(0, 0, 1, 0, 1, 0, 1, 1, 0, 1).To3D())
+	n.Inset(0.1, "a", "b", "c", "d", "e")
+	if count := len(n.Outer.Vertices); count != 4 {
+		t.Errorf("Expected the repeated vertex to be dropped, got %v vertices", count)
+	}
+	if area := n.Outer.Area(); math.Abs(area-0.64) > 1e-9 {
+		t.Errorf("Expected area 0.64, got %v", area)
+	}
+	var tags []string
+	for _, node := range n.Nodes()[1:] {
+		tags = append(tags, node.Tag)
+	}
+	if strings.Join(tags, " ") != "a c d e" {
+		t.Errorf("Expected quads for every edge but the empty one, got %v", tags)
+	}
+}
+
+func TestNodesInset(t *testing.T) {
+	pair := func() Nodes {
+		a := NewNode(Square(1, 1).To3D())
+		b := NewNode(Square(1, 1).To3D())
+		b.Translate(1, 0, 0)
+		NewLinkedNodes(a, b)
+		return Nodes{a, b}
+	}
+
+	ns := pair()
+	ns.Inset(0.1, InsetIndividual)
+	if count := len(ns[0].Nodes()); count != 10 {
+		t.Errorf("Expected 10 nodes, got %v", count)
+	}
+	if area := ns[0].Outer.Area() + ns[1].Outer.Area(); math.Abs(area-2*0.64) > 1e-9 {
+		t.Errorf("Expected area 1.28, got %v", area)
+	}
+
+	// the edge between them stays put
+	ns = pair()
+	ns.Inset(0.1, InsetRegion)
+	if count := len(ns[0].Nodes()); count != 2+6 {
+		t.Errorf("Expected 8 nodes, got %v", count)
+	}
+	if area := ns[0].Outer.Area() + ns[1].Outer.Area(); math.Abs(area-1.8*0.8) > 1e-9 {
+		t.Errorf("Expected area 1.44, got %v", area)
+	}
+	total := 0.0
+	for _, node := range ns[0].Nodes() {
+		total += node.Outer.Area()
+	}
+	if math.Abs(total-2) > 1e-9 {
+		t.Errorf("Expected the faces to still cover 2, got %v", total)
+	}
+}
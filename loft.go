@@ -0,0 +1,221 @@
+package toothpaste
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+type LoftOptions struct {
+	Samples int  // fewest vertices round each section, more are added where sections have them
+	NoCaps  bool // leave the first and last sections open
+}
+
+// Loft joins up a sequence of cross sections into a solid, like going
+// from a square to a circle to a star. The sections don't need the same
+// number of vertices: each one is given extra vertices along its edges
+// until they all match, and then turned round so that its vertices line
+// up with the section before. Holes are lofted too when every section
+// has the same number of them. Faces can be used as sections with
+// NewNode, or from a Face2D with To3D. A section can be a single point,
+// like the tip of a cone, in which case the sides meet there and it
+// isn't capped. The sides between sections i and i+1 are tagged
+// "span<i>", and the caps "start" and "end"
+func Loft(sections []*Node, opts ...LoftOptions) *Node {
+	var opt LoftOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if len(sections) < 2 {
+		return nil
+	}
+	holes := len(sections[0].Inner)
+	for _, section := range sections {
+		if len(section.Inner) != holes {
+			holes = 0
+		}
+	}
+
+	centroids := make([]*Vertex3D, len(sections))
+	for i, section := range sections {
+		centroids[i] = section.Outer.Centroid()
+	}
+	// rings[k][i] is ring k (the outer then the holes) of section i
+	rings := make([][][]*Vertex3D, holes+1)
+	for k := range rings {
+		count := opt.Samples
+		for _, section := range sections {
+			if m := len(section.Faces()[k].Vertices); m > count {
+				count = m
+			}
+		}
+		for i, section := range sections {
+			vertices := resampleRing3D(section.Faces()[k].Vertices, count)
+			// going round anticlockwise looking along the loft, and holes
+			// the other way
+			prev, next := i-1, i+1
+			if prev < 0 {
+				prev = 0
+			}
+			if next == len(sections) {
+				next = i
+			}
+			ahead := centroids[next].Subtract(centroids[prev])
+			if ahead.Norm() == 0 {
+				ahead = newFacePlane(sections[0].Outer.Vertices).normal
+			}
+			if (newFacePlane(vertices).normal.Dot(ahead) < 0) == (k == 0) {
+				reverse3D(vertices)
+			}
+			if i > 0 {
+				vertices = alignRing3D(vertices, rings[k][i-1], centroids[i], centroids[i-1])
+			}
+			rings[k] = append(rings[k], vertices)
+		}
+	}
+
+	var nodes []*Node
+	for i := 0; i+1 < len(sections); i++ {
+		tag := fmt.Sprintf("span%d", i)
+		for _, ring := range rings {
+			a, b := ring[i], ring[i+1]
+			for e := range a {
+				f := (e + 1) % len(a)
+				// sections that are a single point give triangles
+				var vertices []*Vertex3D
+				for _, v := range []*Vertex3D{a[e], a[f], b[f], b[e]} {
+					if len(vertices) == 0 || vertices[len(vertices)-1] != v {
+						vertices = append(vertices, v)
+					}
+				}
+				if len(vertices) > 1 && vertices[0] == vertices[len(vertices)-1] {
+					vertices = vertices[:len(vertices)-1]
+				}
+				if len(vertices) < 3 {
+					continue
+				}
+				nodes = append(nodes, NewTaggedNode(tag, &Face3D{Vertices: vertices}))
+			}
+		}
+	}
+	if !opt.NoCaps {
+		for _, end := range []int{0, len(sections) - 1} {
+			if isPoint3D(rings[0][end]) {
+				// nothing to close off
+				continue
+			}
+			var faces []*Face3D
+			for _, ring := range rings {
+				face := &Face3D{Vertices: append([]*Vertex3D{}, ring[end]...)}
+				// the start looks back the way the loft came
+				if end == 0 {
+					face.Flip()
+				}
+				faces = append(faces, face)
+			}
+			tag := "start"
+			if end != 0 {
+				tag = "end"
+			}
+			nodes = append(nodes, NewTaggedNode(tag, faces[0], faces[1:]...))
+		}
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+	NewLinkedNodes(nodes...)
+	return nodes[0]
+}
+
+// resampleRing3D adds vertices along the edges of a ring until it has n,
+// sharing them out by edge length so that the shape stays the same. The
+// original vertices are kept, as copies. A ring with no length is a
+// point, which is given as one copy n times over
+func resampleRing3D(vertices []*Vertex3D, n int) []*Vertex3D {
+	m := len(vertices)
+	perimeter := 0.0
+	lengths := make([]float64, m)
+	for i, v := range vertices {
+		lengths[i] = v.Distance(vertices[(i+1)%m])
+		perimeter += lengths[i]
+	}
+	if perimeter == 0 && m > 0 {
+		res := make([]*Vertex3D, n)
+		p := vertices[0].Copy()
+		for i := range res {
+			res[i] = p
+		}
+		return res
+	}
+	extra := make([]int, m)
+	if n > m {
+		// largest remainder, so the total comes out exactly
+		order := make([]int, m)
+		added := 0
+		for i := range order {
+			order[i] = i
+			share := float64(n-m) * lengths[i] / perimeter
+			extra[i] = int(share)
+			added += extra[i]
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			ra := float64(n-m)*lengths[order[a]]/perimeter - float64(extra[order[a]])
+			rb := float64(n-m)*lengths[order[b]]/perimeter - float64(extra[order[b]])
+			return ra > rb
+		})
+		for i := 0; added < n-m; i++ {
+			extra[order[i%m]]++
+			added++
+		}
+	}
+	var res []*Vertex3D
+	for i, v := range vertices {
+		next := vertices[(i+1)%m]
+		res = append(res, v.Copy())
+		for j := 1; j <= extra[i]; j++ {
+			t := float64(j) / float64(extra[i]+1)
+			res = append(res, NewVertex3DWithUV(
+				lerp(v.X, next.X, t), lerp(v.Y, next.Y, t), lerp(v.Z, next.Z, t),
+				lerp(v.U, next.U, t), lerp(v.V, next.V, t),
+			))
+		}
+	}
+	return res
+}
+
+// alignRing3D turns the ring round so its vertices are as close as they
+// can be to the ones in the previous ring, measured from each centre
+func alignRing3D(vertices, previous []*Vertex3D, centre, previousCentre *Vertex3D) []*Vertex3D {
+	n := len(vertices)
+	best, shift := math.Inf(1), 0
+	for s := 0; s < n; s++ {
+		total := 0.0
+		for i, p := range previous {
+			v := vertices[(i+s)%n]
+			dx := (v.X - centre.X) - (p.X - previousCentre.X)
+			dy := (v.Y - centre.Y) - (p.Y - previousCentre.Y)
+			dz := (v.Z - centre.Z) - (p.Z - previousCentre.Z)
+			total += dx*dx + dy*dy + dz*dz
+		}
+		if total < best {
+			best, shift = total, s
+		}
+	}
+	return append(append([]*Vertex3D{}, vertices[shift:]...), vertices[:shift]...)
+}
+
+// isPoint3D reports whether every vertex of the ring is the same one
+func isPoint3D(vertices []*Vertex3D) bool {
+	for _, v := range vertices {
+		if v != vertices[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func reverse3D(vertices []*Vertex3D) {
+	for i, j := 0, len(vertices)-1; i < j; i, j = i+1, j-1 {
+		vertices[i], vertices[j] = vertices[j], vertices[i]
+	}
+}
@@ -0,0 +1,94 @@
+package toothpaste
+
+import (
+	"math"
+	"testing"
+)
+
+// section puts a face flat at the given height
+func section(f *Face2D, height float64) *Node {
+	n := NewNode(f.To3D())
+	n.Translate(0, height, 0)
+	return n
+}
+
+func TestLoft(t *testing.T) {
+	// a square to a smaller square drawn with twice the vertices, which
+	// still makes an exact frustum
+	small := NewFace2D(0.5, 0.5, 1, 0.5, 1.5, 0.5, 1.5, 1, 1.5, 1.5, 1, 1.5, 0.5, 1.5, 0.5, 1)
+	n := Loft([]*Node{section(Square(2, 2), 0), section(small, 1)})
+	if count := len(n.Nodes()); count != 8+2 {
+		t.Fatalf("Expected 10 nodes, got %v", count)
+	}
+	if v := volume(n); math.Abs(v-7.0/3) > 1e-9 {
+		t.Errorf("Expected volume 7/3, got %v", v)
+	}
+
+	// square to circle to star, which all face the same way out
+	sections := []*Node{section(Square(2, 2), 0), section(Circle(2, 2, 24), 1), section(Star(2, 2, 5, 0.5), 2)}
+	n = Loft(sections, LoftOptions{Samples: 30})
+	if count := len(n.GetAll("span0")); count != 30 {
+		t.Errorf("Expected 30 sides in the first span, got %v", count)
+	}
+	if count := len(n.GetAll("span1")); count != 30 {
+		t.Errorf("Expected 30 sides in the second span, got %v", count)
+	}
+	if v := volume(n); v <= 0 || v > 8 {
+		t.Errorf("Expected a positive volume less than 8, got %v", v)
+	}
+	if n.Get("start").Outer.Normal().Y > -0.99 || n.Get("end").Outer.Normal().Y < 0.99 {
+		t.Errorf("Expected the caps to face out of the ends")
+	}
+}
+
+func TestLoftPoint(t *testing.T) {
+	// a square up to a point is a pyramid, with triangles for sides
+	apex := NewNode(&Face3D{Vertices: []*Vertex3D{NewVertex3D(1, 1, 1), NewVertex3D(1, 1, 1), NewVertex3D(1, 1, 1)}})
+	n := Loft([]*Node{section(Square(2, 2), 0), apex})
+	if count := len(n.Nodes()); count != 4+1 {
+		t.Fatalf("Expected 4 sides and a base, got %v", count)
+	}
+	for _, node := range n.GetAll("span0") {
+		if len(node.Outer.Vertices) != 3 {
+			t.Errorf("Expected triangles, got %v", node.Outer.Vertices)
+		}
+	}
+	if n.Get("end") != nil {
+		t.Errorf("Expected the point not to be capped")
+	}
+	if v := volume(n); math.Abs(v-4.0/3) > 1e-9 {
+		t.Errorf("Expected volume 4/3, got %v", v)
+	}
+
+	// and a single vertex works the same way, at either end
+	tip := NewNode(&Face3D{Vertices: []*Vertex3D{NewVertex3D(1, -1, 1)}})
+	n = Loft([]*Node{tip, section(Circle(2, 2, 16), 0)})
+	if v := volume(n); v <= 0 {
+		t.Errorf("Expected a positive volume, got %v", v)
+	}
+}
+
+func TestLoftHoles(t *testing.T) {
+	ring := func(height float64) *Node {
+		n := NewShape2D(Square(2, 2), NewFace2D(0.5, 0.5, 1.5, 0.5, 1.5, 1.5, 0.5, 1.5)).Node()
+		n.Translate(0, height, 0)
+		return n
+	}
+	n := Loft([]*Node{ring(0), ring(1)}, LoftOptions{NoCaps: true})
+	if count := len(n.Nodes()); count != 8 {
+		t.Errorf("Expected 8 sides, got %v", count)
+	}
+
+	n = Loft([]*Node{ring(0), ring(1)})
+	if len(n.Get("end").Inner) != 1 {
+		t.Errorf("Expected the end to have a hole")
+	}
+	// the sides round the hole face into it
+	for _, node := range n.GetAll("span0")[4:] {
+		centre := node.Outer.Centroid()
+		normal := node.Outer.Normal()
+		if (centre.X-1)*normal.X+(centre.Z-1)*normal.Z >= 0 {
+			t.Errorf("Expected the hole's sides to face inwards, got %v", normal)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package toothpaste
+
+import (
+	"math"
+)
+
+// MinkowskiSum returns the area covered by other as its origin is moved
+// over every point of the face, like tracing round the face with other
+// as the pen. Two convex faces give one exact convex face, otherwise
+// the faces are split into convex pieces and the sums of the pieces
+// are joined up, which can leave holes
+func (f *Face2D) MinkowskiSum(other *Face2D) []*Shape2D {
+	a, b := ccwRing(f.Vertices), ccwRing(other.Vertices)
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+	piecesA, piecesB := convexPieces(a), convexPieces(b)
+	var rings []*Face2D
+	for _, pa := range piecesA {
+		for _, pb := range piecesB {
+			if sum := convexSum(pa, pb); len(sum) >= 3 {
+				rings = append(rings, &Face2D{Vertices: sum})
+			}
+		}
+	}
+	return unionConvex(rings)
+}
+
+// SweptArea returns the area covered as the face is moved along the
+// path, with its origin following each point in turn
+func (f *Face2D) SweptArea(path ...*Vertex2D) []*Shape2D {
+	vertices := ccwRing(f.Vertices)
+	if len(vertices) == 0 || len(path) == 0 {
+		return nil
+	}
+	if len(path) == 1 {
+		path = append(path, path[0])
+	}
+	var rings []*Face2D
+	for _, piece := range convexPieces(vertices) {
+		for i := 0; i+1 < len(path); i++ {
+			// a convex piece moving in a straight line covers the hull of
+			// where it starts and ends
+			hull := &Face2D{}
+			for _, p := range path[i : i+2] {
+				for _, v := range piece {
+					hull.Vertices = append(hull.Vertices, NewVertex2D(v.X+p.X, v.Y+p.Y))
+				}
+			}
+			if hull = hull.ConvexHull(); len(hull.Vertices) >= 3 {
+				rings = append(rings, hull)
+			}
+		}
+	}
+	return unionConvex(rings)
+}
+
+// unionConvex joins up counter-clockwise rings, skipping the work when
+// there is only one
+func unionConvex(rings []*Face2D) []*Shape2D {
+	if len(rings) == 1 {
+		return []*Shape2D{NewShape2D(rings[0])}
+	}
+	inside := func(x, y float64) bool {
+		for _, ring := range rings {
+			if windingNumber(x, y, ring.Vertices) > 0 {
+				return true
+			}
+		}
+		return false
+	}
+	return fillRings(rings, inside)
+}
+
+// ccwRing returns the vertices without duplicates, counter-clockwise
+func ccwRing(vertices []*Vertex2D) []*Vertex2D {
+	res := append([]*Vertex2D{}, cleanRing(vertices, 0)...)
+	if len(res) < 3 || signedArea(res) == 0 {
+		return nil
+	}
+	if signedArea(res) < 0 {
+		reverseVertices(res)
+	}
+	return res
+}
+
+func isConvex(vertices []*Vertex2D, tolerance float64) bool {
+	n := len(vertices)
+	for i, v := range vertices {
+		prev, next := vertices[(i+n-1)%n], vertices[(i+1)%n]
+		if (v.X-prev.X)*(next.Y-v.Y)-(v.Y-prev.Y)*(next.X-v.X) < -tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// convexSum adds two convex counter-clockwise rings by merging their
+// edges in order of angle, starting from the lowest vertex of each
+func convexSum(a, b []*Vertex2D) []*Vertex2D {
+	lowest := func(vertices []*Vertex2D) int {
+		index := 0
+		for i, v := range vertices {
+			if v.Y < vertices[index].Y || (v.Y == vertices[index].Y && v.X < vertices[index].X) {
+				index = i
+			}
+		}
+		return index
+	}
+	n, m := len(a), len(b)
+	ia, ib := lowest(a), lowest(b)
+	var res []*Vertex2D
+	for i, j := 0, 0; i < n || j < m; {
+		va, vb := a[(ia+i)%n], b[(ib+j)%m]
+		res = append(res, NewVertex2D(va.X+vb.X, va.Y+vb.Y))
+		na, nb := a[(ia+i+1)%n], b[(ib+j+1)%m]
+		cross := (na.X-va.X)*(nb.Y-vb.Y) - (na.Y-va.Y)*(nb.X-vb.X)
+		switch {
+		case j == m || (i < n && cross > 0):
+			i++
+		case i == n || cross < 0:
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	var all []*Vertex2D
+	all = append(all, a...)
+	all = append(all, b...)
+	return removeCollinear2D(cleanRing(res, 0), ringTolerance(all))
+}
+
+// convexPieces splits a counter-clockwise ring into convex pieces, by
+// cutting it into triangles and then joining triangles back together
+// wherever the result stays convex (Hertel-Mehlhorn)
+func convexPieces(vertices []*Vertex2D) [][]*Vertex2D {
+	tolerance := ringTolerance(vertices)
+	if isConvex(vertices, tolerance*tolerance) {
+		return [][]*Vertex2D{vertices}
+	}
+	cross := func(a, b, c int) float64 {
+		pa, pb, pc := vertices[a], vertices[b], vertices[c]
+		return (pb.X-pa.X)*(pc.Y-pa.Y) - (pb.Y-pa.Y)*(pc.X-pa.X)
+	}
+
+	// ear clipping
+	var pieces [][]int
+	remaining := make([]int, len(vertices))
+	for i := range remaining {
+		remaining[i] = i
+	}
+	for len(remaining) > 3 {
+		m := len(remaining)
+		ear, flattest := -1, -1
+		for i := range remaining {
+			a, b, c := remaining[(i+m-1)%m], remaining[i], remaining[(i+1)%m]
+			area := cross(a, b, c)
+			if math.Abs(area) <= tolerance*tolerance && flattest == -1 {
+				flattest = i
+			}
+			if area <= tolerance*tolerance {
+				continue
+			}
+			empty := true
+			for _, j := range remaining {
+				if j != a && j != b && j != c && cross(a, b, j) >= 0 && cross(b, c, j) >= 0 && cross(c, a, j) >= 0 {
+					empty = false
+					break
+				}
+			}
+			if empty {
+				ear = i
+				break
+			}
+		}
+		if ear == -1 {
+			if flattest == -1 {
+				// shouldn't happen for a simple ring, but don't loop forever
+				break
+			}
+			// a vertex on a straight line can go without cutting anything
+			remaining = append(remaining[:flattest], remaining[flattest+1:]...)
+			continue
+		}
+		pieces = append(pieces, []int{remaining[(ear+m-1)%m], remaining[ear], remaining[(ear+1)%m]})
+		remaining = append(remaining[:ear], remaining[ear+1:]...)
+	}
+	pieces = append(pieces, remaining)
+
+	convex := func(piece []int) bool {
+		n := len(piece)
+		for i := range piece {
+			if cross(piece[(i+n-1)%n], piece[i], piece[(i+1)%n]) < -tolerance*tolerance {
+				return false
+			}
+		}
+		return true
+	}
+	// rotate returns the piece starting just after the edge from, to and
+	// ending with from, or nil if it doesn't have that edge
+	rotate := func(piece []int, from, to int) []int {
+		for i, v := range piece {
+			if v == from && piece[(i+1)%len(piece)] == to {
+				return append(append([]int{}, piece[i+1:]...), piece[:i+1]...)
+			}
+		}
+		return nil
+	}
+	for merged := true; merged; {
+		merged = false
+		for i := 0; i < len(pieces) && !merged; i++ {
+			for j := i + 1; j < len(pieces) && !merged; j++ {
+				for k, from := range pieces[i] {
+					to := pieces[i][(k+1)%len(pieces[i])]
+					other := rotate(pieces[j], to, from)
+					if other == nil {
+						continue
+					}
+					// round this piece from to back to from, then round the
+					// other between them
+					joined := append(rotate(pieces[i], from, to), other[1:len(other)-1]...)
+					if convex(joined) {
+						pieces[i] = joined
+						pieces = append(pieces[:j], pieces[j+1:]...)
+						merged = true
+					}
+					break
+				}
+			}
+		}
+	}
+
+	var res [][]*Vertex2D
+	for _, piece := range pieces {
+		var ring []*Vertex2D
+		for _, i := range piece {
+			ring = append(ring, vertices[i])
+		}
+		res = append(res, ring)
+	}
+	return res
+}
@@ -0,0 +1,66 @@
+package toothpaste
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMinkowskiSumConvex(t *testing.T) {
+	shapes := Square(2, 2).MinkowskiSum(NewFace2D(-0.5, -0.5, 0.5, -0.5, 0.5, 0.5, -0.5, 0.5))
+	if len(shapes) != 1 || len(shapes[0].Outer.Vertices) != 4 {
+		t.Fatalf("Expected a square, got %v", shapes)
+	}
+	if area := shapesArea(shapes); math.Abs(area-9) > 1e-9 {
+		t.Errorf("Expected area 9, got %v", area)
+	}
+
+	// a square and a diamond make an octagon
+	diamond := NewFace2D(0, -1, 1, 0, 0, 1, -1, 0)
+	shapes = Square(2, 2).MinkowskiSum(diamond)
+	if len(shapes) != 1 || len(shapes[0].Outer.Vertices) != 8 {
+		t.Fatalf("Expected an octagon, got %v", shapes)
+	}
+	if area := shapesArea(shapes); math.Abs(area-14) > 1e-9 {
+		t.Errorf("Expected area 14, got %v", area)
+	}
+}
+
+func TestMinkowskiSumConcave(t *testing.T) {
+	l := NewFace2D(0, 0, 2, 0, 2, 1, 1, 1, 1, 2, 0, 2)
+	square := NewFace2D(-0.25, -0.25, 0.25, -0.25, 0.25, 0.25, -0.25, 0.25)
+	shapes := l.MinkowskiSum(square)
+	if len(shapes) != 1 {
+		t.Fatalf("Expected 1 shape, got %v", len(shapes))
+	}
+	// the same as growing it with mitred corners
+	if area := shapesArea(shapes); math.Abs(area-shapesArea(l.Offset(0.25, JoinMiter))) > 1e-9 {
+		t.Errorf("Expected area %v, got %v", shapesArea(l.Offset(0.25, JoinMiter)), area)
+	}
+
+	// the slot into the middle of a C closes up, leaving a hole
+	c := NewFace2D(0, 0, 3, 0, 3, 3, 1.6, 3, 1.6, 2, 2, 2, 2, 1, 1, 1, 1, 2, 1.4, 2, 1.4, 3, 0, 3)
+	shapes = c.MinkowskiSum(square)
+	if len(shapes) != 1 || len(shapes[0].Holes) != 1 {
+		t.Fatalf("Expected 1 shape with a hole, got %v", shapes)
+	}
+	if area := shapesArea(shapes); math.Abs(area-12) > 1e-9 {
+		t.Errorf("Expected area 12, got %v", area)
+	}
+}
+
+func TestSweptArea(t *testing.T) {
+	square := NewFace2D(-0.5, -0.5, 0.5, -0.5, 0.5, 0.5, -0.5, 0.5)
+	shapes := square.SweptArea(NewVertex2D(0, 0), NewVertex2D(4, 0))
+	if area := shapesArea(shapes); len(shapes) != 1 || math.Abs(area-5) > 1e-9 {
+		t.Errorf("Expected one 5x1 shape, got %v with area %v", shapes, area)
+	}
+
+	// round a corner and back on itself, leaving a hole in the middle
+	shapes = square.SweptArea(NewVertex2D(0, 0), NewVertex2D(4, 0), NewVertex2D(4, 4), NewVertex2D(0, 4), NewVertex2D(0, 0))
+	if len(shapes) != 1 || len(shapes[0].Holes) != 1 {
+		t.Fatalf("Expected 1 shape with a hole, got %v", shapes)
+	}
+	if area := shapesArea(shapes); math.Abs(area-(25-9)) > 1e-9 {
+		t.Errorf("Expected area 16, got %v", area)
+	}
+}
@@ -1,10 +1,8 @@
 package toothpaste
 
 import (
-	"fmt"
 	"github.com/micah5/earcut-3d"
 	"math"
-	"os"
 	"reflect"
 	//"strings"
 )
@@ -83,8 +81,101 @@ func (n *Node) ExtrudeFlip(height float64, tags ...string) *Node {
 }
 
 func (n *Node) Extrude(height float64, tags ...string) *Node {
-	res := extrude(n, n.Faces(), height, true, tags...)
-	res.GetPrev(n.CountInnerVertices()).Flip()
+	return n.ExtrudeWith(height, ExtrudeOptions{}, tags...)
+}
+
+type ExtrudeOptions struct {
+	Twist     float64   // degrees the top is turned by, anticlockwise around the face's normal
+	Scale     float64   // size of the top compared to the bottom, 1 if not given
+	ScaleXY   *Vertex2D // size of the top along the face's own x and y, instead of Scale
+	Direction *Vertex3D // which way to extrude, away from the normal if not given
+	Steps     int       // rings to split the sides into, so twists and tapers look smooth
+}
+
+// ExtrudeWith extrudes like Extrude, but can twist, taper and lean the
+// extrusion on the way. The top is turned and scaled around the
+// centroid of the face, in its own plane, and moved height along the
+// direction. With more than one step the sides are split into rings,
+// each tagged the same way as Extrude tags the sides. A direction lying
+// in the plane of the face would give a solid with no volume, so the
+// node is left as it is and nil is returned instead
+func (n *Node) ExtrudeWith(height float64, opts ExtrudeOptions, tags ...string) *Node {
+	scaleX, scaleY := opts.Scale, opts.Scale
+	if opts.ScaleXY != nil {
+		scaleX, scaleY = opts.ScaleXY.X, opts.ScaleXY.Y
+	} else if scaleX == 0 {
+		scaleX, scaleY = 1, 1
+	}
+	if opts.Twist == 0 && scaleX == 1 && scaleY == 1 && opts.Direction == nil && opts.Steps <= 1 {
+		res := extrude(n, n.Faces(), height, true, tags...)
+		res.GetPrev(n.CountInnerVertices()).Flip()
+		return res
+	}
+	steps := opts.Steps
+	if steps < 1 {
+		steps = 1
+	}
+
+	plane := newFacePlane(n.Outer.Vertices)
+	centre := n.Outer.Centroid()
+	direction := plane.normal.Negate()
+	if opts.Direction != nil && opts.Direction.Norm() > 0 {
+		direction = opts.Direction.Normalize()
+	}
+	// the sides face out whichever side of the face the top ends up
+	away := height * direction.Dot(plane.normal.Negate())
+	if math.Abs(away) < 1e-9*math.Abs(height) {
+		return nil
+	}
+	place := func(v *Vertex3D, t float64) *Vertex3D {
+		d := v.Subtract(centre)
+		x := d.Dot(plane.u) * lerp(1, scaleX, t)
+		y := d.Dot(plane.v) * lerp(1, scaleY, t)
+		w := d.Dot(plane.normal)
+		angle := opts.Twist * t * math.Pi / 180
+		x, y = x*math.Cos(angle)-y*math.Sin(angle), x*math.Sin(angle)+y*math.Cos(angle)
+		move := height * t
+		return NewLabelledVertex3DWithUV(
+			centre.X+plane.u.X*x+plane.v.X*y+plane.normal.X*w+direction.X*move,
+			centre.Y+plane.u.Y*x+plane.v.Y*y+plane.normal.Y*w+direction.Y*move,
+			centre.Z+plane.u.Z*x+plane.v.Z*y+plane.normal.Z*w+direction.Z*move,
+			v.U, v.V, v.Label,
+		)
+	}
+
+	next := n.Next
+	cur := n
+	base := n.Faces()
+	bottom := base
+	for step := 1; step <= steps; step++ {
+		t := float64(step) / float64(steps)
+		var top []*Face3D
+		for k, f := range bottom {
+			ring := &Face3D{}
+			for _, v := range base[k].Vertices {
+				ring.Vertices = append(ring.Vertices, place(v, t))
+			}
+			// wound the same way as the sides from Extrude
+			for i := range f.Vertices {
+				j := (i + 1) % len(f.Vertices)
+				vertices := []*Vertex3D{ring.Vertices[i], ring.Vertices[j], f.Vertices[j], f.Vertices[i]}
+				if away < 0 {
+					vertices = []*Vertex3D{f.Vertices[i], f.Vertices[j], ring.Vertices[j], ring.Vertices[i]}
+				}
+				side := NewTaggedNode(getTag(i+1, tags), &Face3D{Vertices: vertices})
+				cur.Next, side.Prev = side, cur
+				cur = side
+			}
+			top = append(top, ring)
+		}
+		bottom = top
+	}
+	res := NewTaggedNode(getTag(0, tags), bottom[0], bottom[1:]...)
+	cur.Next, res.Prev = res, cur
+	res.Next = next
+	if next != nil {
+		next.Prev = res
+	}
 	return res
 }
 
@@ -553,9 +644,15 @@ func (n *Node) AddTexture(texturePath string, uvCoords ...*Vertex2D) {
 	n.ImageTexture = true
 }
 
-func (n *Node) Center() {
+// Center moves the nodes so their centroid is at the origin. By default
+// that is the average of the face centroids, pass true to use the
+// centroid of the surface area instead
+func (n *Node) Center(useArea ...bool) {
 	ns := n.Nodes()
 	centroid := ns.Centroid()
+	if len(useArea) > 0 && useArea[0] {
+		centroid = ns.AreaCentroid()
+	}
 	ns.Translate(-centroid.X, -centroid.Y, -centroid.Z)
 }
 
@@ -601,186 +698,6 @@ func (n *Node) Reverse() {
 	n.Flip()
 }
 
-func (n *Node) Generate(filename string) {
-	nodes := n.Nodes()
-	var faces [][]float64
-	var holes [][][]float64
-	for _, node := range nodes {
-		faces = append(faces, node.Outer.Flatten())
-		_holes := make([][]float64, 0)
-		for _, inner := range node.Inner {
-			_holes = append(_holes, inner.Flatten())
-		}
-		holes = append(holes, _holes)
-	}
-	triangles := earcut3d.Earcut(faces, holes...)
-	earcut3d.CreateObjFile(filename, triangles)
-}
-
-func (node *Node) GenerateColor(name string, _colors ...map[string][3]float64) {
-	colors := map[string][3]float64{}
-	if len(_colors) > 0 {
-		colors = _colors[0]
-	}
-	nodes := node.Nodes()
-	var faces [][]float64
-	var holes [][][]float64
-	for _, node := range nodes {
-		faces = append(faces, node.Outer.Flatten())
-		_holes := make([][]float64, 0)
-		for _, inner := range node.Inner {
-			_holes = append(_holes, inner.Flatten())
-		}
-		holes = append(holes, _holes)
-	}
-	faces2 := earcut3d.EarcutFaces(faces, holes...)
-
-	// find all the unique uv coordinates
-	uniqueUVs := make(map[[2]float64]int)
-	for _, n := range nodes {
-		for _, vertex := range n.Outer.Vertices {
-			uniqueUVs[[2]float64{vertex.U, vertex.V}] = 1
-		}
-	}
-	uvIndices := make(map[[2]float64]int)
-	for uvCoord := range uniqueUVs {
-		uvIndices[uvCoord] = len(uvIndices) + 1
-	}
-
-	// Create obj file
-	f, err := os.Create(name)
-	if err != nil {
-		panic(err)
-	}
-	defer f.Close()
-
-	// Create a map to store unique vertices and their indices
-	vertexIndices := make(map[[3]float64]int)
-	currentIndex := 1
-
-	// Write header
-	mtlFilename := name[:len(name)-4]
-	f.WriteString("mtllib " + mtlFilename + ".mtl\n")
-	colors["default"] = [3]float64{1.0, 1.0, 1.0}
-
-	// Write triangles
-	for _, face := range faces2 {
-		for _, triangleArray := range face {
-			for i := 0; i < len(triangleArray); i += 3 {
-				// If the vertex hasn't been seen before, write it and store its index
-				key := [3]float64{triangleArray[i], triangleArray[i+1], triangleArray[i+2]}
-				if _, seen := vertexIndices[key]; !seen {
-					f.WriteString(fmt.Sprintf("v %f %f %f\n", triangleArray[i], triangleArray[i+1], triangleArray[i+2]))
-					vertexIndices[key] = currentIndex
-					currentIndex++
-				}
-			}
-		}
-	}
-
-	// Organise triangles by tag
-	type TypedTag struct {
-		ImageTexture bool
-		Path         string
-		Index        int
-	}
-	trianglesByTag := make(map[string][][]float64)
-	nodeIndicesByTag := make(map[string][]int)
-	metaTag := make(map[string]TypedTag)
-	normalIndices := make(map[int]int)
-	for i, face := range faces2 {
-		tag := nodes[i].Tag
-		if tag == "" {
-			tag = "default"
-		}
-		imageTexture := nodes[i].ImageTexture
-		if imageTexture {
-			path := nodes[i].Tag
-			// split on / and take the last element, also remove the .png or .jpg or whatever
-			//tag = strings.Split(tag, "/")[len(strings.Split(tag, "/"))-1]
-			//tag = strings.Split(tag, ".")[0]
-			tag := path
-			metaTag[tag] = TypedTag{
-				ImageTexture: imageTexture,
-				Path:         path,
-				Index:        i,
-			}
-		}
-		for _, triangleArray := range face {
-			trianglesByTag[tag] = append(trianglesByTag[tag], triangleArray)
-			nodeIndicesByTag[tag] = append(nodeIndicesByTag[tag], i)
-		}
-
-		// Write normal
-		normal := nodes[i].Outer.Normal()
-		f.WriteString(fmt.Sprintf("vn %f %f %f\n", normal.X, normal.Y, normal.Z))
-		normalIndices[i] = i + 1
-	}
-
-	// Write texture coordinates
-	sortedUvIndices := make([][2]float64, len(uvIndices))
-	for uvCoord, index := range uvIndices {
-		sortedUvIndices[index-1] = uvCoord
-	}
-	for _, uvCoord := range sortedUvIndices {
-		f.WriteString(fmt.Sprintf("vt %f %f\n", uvCoord[0], uvCoord[1]))
-	}
-
-	// Write faces
-	for tag, triangles := range trianglesByTag {
-		f.WriteString(fmt.Sprintf("usemtl %s\n", tag))
-		for t_i, triangleArray := range triangles {
-			f.WriteString("f")
-			for i := 0; i < len(triangleArray); i += 3 {
-				key := [3]float64{triangleArray[i], triangleArray[i+1], triangleArray[i+2]}
-
-				// check if the vertex has a uv coordinate
-				uvIndex := -1
-				meta := metaTag[tag]
-				if meta.ImageTexture {
-					for _, idx := range nodeIndicesByTag[tag] {
-						for _, n := range nodes[idx].Outer.Vertices {
-							if n.X == key[0] && n.Y == key[1] && n.Z == key[2] {
-								uvIndex = uvIndices[[2]float64{n.U, n.V}]
-								break
-							}
-						}
-					}
-				}
-
-				// Write vertex, texture, and normal indices
-				normalIndex := normalIndices[nodeIndicesByTag[tag][t_i]]
-				if uvIndex != -1 {
-					f.WriteString(fmt.Sprintf(" %d/%d/%d", vertexIndices[key], uvIndex, normalIndex))
-				} else {
-					f.WriteString(fmt.Sprintf(" %d//%d", vertexIndices[key], normalIndex))
-				}
-				normalIndex += 1
-			}
-			f.WriteString("\n")
-		}
-	}
-
-	// Create mtl file
-	// remove .obj from name
-	f, err = os.Create(mtlFilename + ".mtl")
-	if err != nil {
-		panic(err)
-	}
-
-	// Write materials
-	for tag, _ := range trianglesByTag {
-		f.WriteString(fmt.Sprintf("newmtl %s\n", tag))
-		meta := metaTag[tag]
-		if meta.ImageTexture {
-			f.WriteString(fmt.Sprintf("map_Kd %s\n", meta.Path))
-		} else {
-			color := colors[tag]
-			f.WriteString(fmt.Sprintf("Kd %f %f %f\n", color[0], color[1], color[2]))
-		}
-	}
-}
-
 func (n *Node) getBounds() (minX, minY, minZ, maxX, maxY, maxZ float64) {
 	nodes := n.Nodes()
 	minX, minY, minZ = math.Inf(1), math.Inf(1), math.Inf(1)
@@ -848,6 +765,100 @@ func (ns Nodes) Centroid() *Vertex3D {
 	return NewVertex3D(sumX/float64(len(ns)), sumY/float64(len(ns)), sumZ/float64(len(ns)))
 }
 
+// Area is the area of the outer face minus its holes
+func (n *Node) Area() float64 {
+	area := n.Outer.Area()
+	for _, inner := range n.Inner {
+		area -= inner.Area()
+	}
+	return area
+}
+
+// AreaCentroid is the centroid of the surface of all the nodes, with
+// each face weighted by its area and holes taken away
+func (ns Nodes) AreaCentroid() *Vertex3D {
+	centroid := NewVertex3D(0, 0, 0)
+	var total float64
+	add := func(f *Face3D, sign float64) {
+		area := f.Area() * sign
+		c := f.AreaCentroid()
+		c.Mul(area)
+		centroid = centroid.Add(c)
+		total += area
+	}
+	for _, node := range ns {
+		add(node.Outer, 1)
+		for _, inner := range node.Inner {
+			add(inner, -1)
+		}
+	}
+	if total == 0 {
+		return ns.Centroid()
+	}
+	centroid.Mul(1 / total)
+	return centroid
+}
+
+// triangulate runs earcut over each node's outer face and holes,
+// returning the flattened triangles grouped in the same order as ns
+func (ns Nodes) triangulate() [][][]float64 {
+	var faces [][]float64
+	var holes [][][]float64
+	for _, node := range ns {
+		faces = append(faces, node.Outer.Flatten())
+		_holes := make([][]float64, 0)
+		for _, inner := range node.Inner {
+			_holes = append(_holes, inner.Flatten())
+		}
+		holes = append(holes, _holes)
+	}
+	return earcut3d.EarcutFaces(faces, holes...)
+}
+
+// orientedTriangles triangulates ns like triangulate, but returns each
+// triangle as vertices wound to agree with the normal of its node. The
+// texture coordinates are taken from the closest vertex of the node
+func (ns Nodes) orientedTriangles() [][][3]*Vertex3D {
+	faces := ns.triangulate()
+	res := make([][][3]*Vertex3D, len(faces))
+	for i, face := range faces {
+		node := ns[i]
+		normal := node.Outer.Normal()
+		for _, t := range face {
+			var tri [3]*Vertex3D
+			for j := 0; j < 3; j++ {
+				v := NewVertex3D(t[j*3], t[j*3+1], t[j*3+2])
+				if closest := node.closestVertex(v); closest != nil {
+					v.UV(closest.U, closest.V)
+					v.Label = closest.Label
+				}
+				tri[j] = v
+			}
+			// earcut doesn't guarantee the winding of its output
+			if tri[1].Subtract(tri[0]).Cross(tri[2].Subtract(tri[0])).Dot(normal) < 0 {
+				tri[1], tri[2] = tri[2], tri[1]
+			}
+			res[i] = append(res[i], tri)
+		}
+	}
+	return res
+}
+
+func (n *Node) closestVertex(point *Vertex3D) *Vertex3D {
+	var closest *Vertex3D
+	var closestDist float64
+	for _, f := range n.Faces() {
+		for _, vertex := range f.Vertices {
+			dist := vertex.Distance(point)
+			if closest == nil || dist < closestDist {
+				closest = vertex
+				closestDist = dist
+			}
+		}
+	}
+	return closest
+}
+
 func (ns Nodes) UniqueVertices() []*Vertex3D {
 	unique := make(map[string]*Vertex3D)
 	for _, node := range ns {
@@ -998,6 +1009,19 @@ func (ns Nodes) Extrude(height float64, tags ...string) Nodes {
 	return nodes
 }
 
+// ExtrudeWith extrudes each node, see Node.ExtrudeWith. Nodes that
+// can't be extruded along the direction are left out of the result, so
+// it can be shorter than ns
+func (ns Nodes) ExtrudeWith(height float64, opts ExtrudeOptions, tags ...string) Nodes {
+	var nodes Nodes
+	for _, node := range ns {
+		if top := node.ExtrudeWith(height, opts, tags...); top != nil {
+			nodes = append(nodes, top)
+		}
+	}
+	return nodes
+}
+
 func (ns Nodes) ExtrudeFlip(height float64, tags ...string) Nodes {
 	var nodes Nodes
 	for _, node := range ns {
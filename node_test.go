@@ -1,6 +1,7 @@
 package toothpaste
 
 import (
+	"math"
 	"reflect"
 	"testing"
 )
@@ -47,12 +48,12 @@ func TestUniqueVertices(t *testing.T) {
 	}
 	unique := nodes.UniqueVertices()
 	expected := []*Vertex3D{
-		{0, 1, 0},
-		{0, 1, -2},
-		{0, 0, -2},
-		{0, 0, 0},
-		{1, 1, -3},
-		{1, 0, -3},
+		{X: 0, Y: 1, Z: 0},
+		{X: 0, Y: 1, Z: -2},
+		{X: 0, Y: 0, Z: -2},
+		{X: 0, Y: 0, Z: 0},
+		{X: 1, Y: 1, Z: -3},
+		{X: 1, Y: 0, Z: -3},
 	}
 	if !isEqualSliceUnordered(unique, expected) {
 		t.Errorf("Expected %v, got %v", expected, unique)
@@ -159,13 +160,13 @@ func TestFlip(t *testing.T) {
 	// check that the vertices are in the correct order
 	// i.e it needs to be split in half, reversed, then joined back together
 	expected := []*Vertex3D{
-		{3, 0, 0},
-		{2, 0, 0},
-		{1, 0, 0},
-		{0, 0, 0},
-		{6, 0, 0},
-		{5, 0, 0},
-		{4, 0, 0},
+		{X: 3, Y: 0, Z: 0},
+		{X: 2, Y: 0, Z: 0},
+		{X: 1, Y: 0, Z: 0},
+		{X: 0, Y: 0, Z: 0},
+		{X: 6, Y: 0, Z: 0},
+		{X: 5, Y: 0, Z: 0},
+		{X: 4, Y: 0, Z: 0},
 	}
 	if !isEqualSliceUnordered(face3d.Vertices, expected) {
 		t.Errorf("Expected %v, got %v", expected, face3d.Vertices)
@@ -197,26 +198,26 @@ func TestFlip(t *testing.T) {
 		t.Errorf("Expected 20 vertices, got %v", len(faceTest3.Vertices))
 	}
 	expected2 := []*Vertex3D{
-		{25.000000, 0.000000, 30.000000},
-		{25.000569, 0.000000, 10.075416},
-		{24.829629, 0.000000, 8.705905},
-		{24.330127, 0.000000, 7.500000},
-		{23.535534, 0.000000, 6.464466},
-		{22.500000, 0.000000, 5.669873},
-		{21.294095, 0.000000, 5.170371},
-		{19.347369, 0.000000, 4.957224},
-		{20.000000, 0.000000, 5.000000},
-		{0.000000, 0.000000, 5.000000},
-		{0.000000, 0.000000, -5.000000},
-		{20.000000, 0.000000, -5.000000},
-		{20.652631, 0.000000, -4.957224},
-		{23.882286, 0.000000, -4.488887},
-		{27.500000, 0.000000, -2.990381},
-		{30.606602, 0.000000, -0.606602},
-		{32.990381, 0.000000, 2.500000},
-		{34.488887, 0.000000, 6.117714},
-		{34.999431, 0.000000, 9.924584},
-		{35.000000, 0.000000, 30.000000},
+		{X: 25.000000, Y: 0.000000, Z: 30.000000},
+		{X: 25.000569, Y: 0.000000, Z: 10.075416},
+		{X: 24.829629, Y: 0.000000, Z: 8.705905},
+		{X: 24.330127, Y: 0.000000, Z: 7.500000},
+		{X: 23.535534, Y: 0.000000, Z: 6.464466},
+		{X: 22.500000, Y: 0.000000, Z: 5.669873},
+		{X: 21.294095, Y: 0.000000, Z: 5.170371},
+		{X: 19.347369, Y: 0.000000, Z: 4.957224},
+		{X: 20.000000, Y: 0.000000, Z: 5.000000},
+		{X: 0.000000, Y: 0.000000, Z: 5.000000},
+		{X: 0.000000, Y: 0.000000, Z: -5.000000},
+		{X: 20.000000, Y: 0.000000, Z: -5.000000},
+		{X: 20.652631, Y: 0.000000, Z: -4.957224},
+		{X: 23.882286, Y: 0.000000, Z: -4.488887},
+		{X: 27.500000, Y: 0.000000, Z: -2.990381},
+		{X: 30.606602, Y: 0.000000, Z: -0.606602},
+		{X: 32.990381, Y: 0.000000, Z: 2.500000},
+		{X: 34.488887, Y: 0.000000, Z: 6.117714},
+		{X: 34.999431, Y: 0.000000, Z: 9.924584},
+		{X: 35.000000, Y: 0.000000, Z: 30.000000},
 	}
 	if !isEqualSliceUnordered(faceTest3.Vertices, expected2) {
 		t.Errorf("Expected %v, got %v", expected2, faceTest3.Vertices)
@@ -414,3 +415,76 @@ func TestDrop(t *testing.T) {
 		t.Errorf("Expected 2 nodes, got %v", len(nodes))
 	}
 }
+
+func TestExtrudeWith(t *testing.T) {
+	// no options is the same as Extrude
+	a := NewNode(Square(2, 2).To3D())
+	top := a.ExtrudeWith(1, ExtrudeOptions{}, "top", "front")
+	if count := len(a.Nodes()); count != 6 || top.Tag != "top" || a.Next.Tag != "front" {
+		t.Errorf("Expected a plain extrusion, got %v nodes", count)
+	}
+
+	// a tapered, twisted column in rings
+	b := NewNode(Square(2, 2).To3D())
+	top = b.ExtrudeWith(2, ExtrudeOptions{Twist: 90, Scale: 0.5, Steps: 4}, "top", "front")
+	if count := len(b.Nodes()); count != 1+4*4+1 {
+		t.Fatalf("Expected 18 nodes, got %v", count)
+	}
+	if count := len(b.GetAll("front")); count != 4 {
+		t.Errorf("Expected a front side in each ring, got %v", count)
+	}
+	if area := top.Outer.Area(); math.Abs(area-1) > 1e-9 {
+		t.Errorf("Expected the top to be scaled to area 1, got %v", area)
+	}
+	centre := top.Outer.Centroid()
+	if math.Abs(centre.X-1) > 1e-9 || math.Abs(centre.Y-2) > 1e-9 || math.Abs(centre.Z-1) > 1e-9 {
+		t.Errorf("Expected the top centred above the bottom, got %v", centre)
+	}
+	// a quarter turn brings the corners back round to the same places
+	for _, v := range top.Outer.Vertices {
+		if math.Abs(math.Abs(v.X-1)-0.5) > 1e-9 || math.Abs(math.Abs(v.Z-1)-0.5) > 1e-9 {
+			t.Errorf("Expected a corner of the smaller square, got %v", v)
+		}
+	}
+	if top.Outer.Vertices[0].Equals(NewVertex3D(0.5, 2, 0.5)) {
+		t.Errorf("Expected the first corner to have turned")
+	}
+
+	// leaning over, with a different scale each way
+	c := NewNode(Square(2, 2).To3D())
+	top = c.ExtrudeWith(1, ExtrudeOptions{Direction: NewVertex3D(1, 1, 0), ScaleXY: NewVertex2D(0.5, 1)})
+	centre = top.Outer.Centroid()
+	if math.Abs(centre.X-1-math.Sqrt(0.5)) > 1e-9 || math.Abs(centre.Y-math.Sqrt(0.5)) > 1e-9 {
+		t.Errorf("Expected the top to lean along the direction, got %v", centre)
+	}
+	if area := top.Outer.Area(); math.Abs(area-2) > 1e-9 {
+		t.Errorf("Expected area 2, got %v", area)
+	}
+
+	// towards the normal instead, the sides still face out
+	d := NewNode(Square(2, 2).To3D())
+	top = d.ExtrudeWith(1, ExtrudeOptions{Direction: d.Outer.Normal(), Scale: 0.5, Steps: 2})
+	if top.Outer.Centroid().Y > -1+1e-9 {
+		t.Errorf("Expected the top below the face, got %v", top.Outer.Centroid())
+	}
+	for _, side := range d.Nodes()[1:9] {
+		centre := side.Outer.Centroid()
+		out := centre.Subtract(NewVertex3D(1, centre.Y, 1))
+		if side.Outer.Normal().Dot(out) <= 0 {
+			t.Errorf("Expected the side to face out, got %v", side.Outer.Normal())
+		}
+	}
+
+	// nothing to extrude along
+	if top := NewNode(Square(2, 2).To3D()).ExtrudeWith(1, ExtrudeOptions{Direction: NewVertex3D(1, 0, 0)}); top != nil {
+		t.Errorf("Expected nil for a direction in the plane of the face")
+	}
+	floor := NewNode(Square(2, 2).To3D())
+	wall := NewNode(Square(2, 2).To3D())
+	wall.Rotate(90, XAxis)
+	tops := Nodes{floor, wall}.ExtrudeWith(1, ExtrudeOptions{Direction: NewVertex3D(0, 1, 0)})
+	if len(tops) != 1 || tops[0] == nil {
+		t.Fatalf("Expected only the floor to be extruded, got %v", tops)
+	}
+	tops.Tag("top")
+}
@@ -0,0 +1,456 @@
+package toothpaste
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/micah5/earcut-3d"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// errWriter remembers the first error so that a long run of writes
+// only has to be checked once at the end
+type errWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func newErrWriter(w io.Writer) *errWriter {
+	return &errWriter{w: bufio.NewWriter(w)}
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, args...)
+}
+
+func (ew *errWriter) flush() error {
+	if ew.err != nil {
+		return ew.err
+	}
+	return ew.w.Flush()
+}
+
+// WriteOBJ writes the node chain to w as a plain OBJ mesh
+func (n *Node) WriteOBJ(w io.Writer) error {
+	nodes := n.Nodes()
+	var faces [][]float64
+	var holes [][][]float64
+	for _, node := range nodes {
+		faces = append(faces, node.Outer.Flatten())
+		_holes := make([][]float64, 0)
+		for _, inner := range node.Inner {
+			_holes = append(_holes, inner.Flatten())
+		}
+		holes = append(holes, _holes)
+	}
+	triangles := earcut3d.Earcut(faces, holes...)
+
+	ew := newErrWriter(w)
+
+	// Write each vertex once, the first time it is seen
+	vertexIndices := make(map[[3]float64]int)
+	currentIndex := 1
+	for _, triangle := range triangles {
+		for i := 0; i < len(triangle); i += 3 {
+			key := [3]float64{triangle[i], triangle[i+1], triangle[i+2]}
+			if _, seen := vertexIndices[key]; !seen {
+				ew.printf("v %f %f %f\n", key[0], key[1], key[2])
+				vertexIndices[key] = currentIndex
+				currentIndex++
+			}
+		}
+	}
+
+	// Write faces
+	for _, triangle := range triangles {
+		ew.printf("f")
+		for i := 0; i < len(triangle); i += 3 {
+			key := [3]float64{triangle[i], triangle[i+1], triangle[i+2]}
+			ew.printf(" %d", vertexIndices[key])
+		}
+		ew.printf("\n")
+	}
+	return ew.flush()
+}
+
+func (n *Node) Generate(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := n.WriteOBJ(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ObjOptions controls the output of WriteColorWithOptions
+type ObjOptions struct {
+	// Colors maps tags to the diffuse color of their material
+	Colors map[string][3]float64
+	// Deterministic sorts the materials by tag and writes the texture
+	// coordinates in the order they are first seen, so that identical
+	// input always gives identical bytes
+	Deterministic bool
+	// FloatFormat is the strconv format used for every number,
+	// 'f' when left empty
+	FloatFormat byte
+	// Precision is the strconv precision used for every number, 6 when
+	// left nil or -1 for the shortest exact representation. It is a
+	// pointer so that 0, for whole numbers, can be told apart from unset
+	Precision *int
+}
+
+func (o ObjOptions) float(f float64) string {
+	format, precision := o.FloatFormat, 6
+	if format == 0 {
+		format = 'f'
+	}
+	if o.Precision != nil {
+		precision = *o.Precision
+	}
+	return strconv.FormatFloat(f, format, precision, 64)
+}
+
+// WriteColor writes the node chain to obj with its materials written to
+// mtl. mtlName is the name the obj file uses to refer to the mtl file
+func (node *Node) WriteColor(obj, mtl io.Writer, mtlName string, _colors ...map[string][3]float64) error {
+	opts := ObjOptions{}
+	if len(_colors) > 0 {
+		opts.Colors = _colors[0]
+	}
+	return node.WriteColorWithOptions(obj, mtl, mtlName, opts)
+}
+
+// WriteColorWithOptions is WriteColor with control over the ordering
+// and number formatting of the output
+func (node *Node) WriteColorWithOptions(obj, mtl io.Writer, mtlName string, opts ObjOptions) error {
+	colors := map[string][3]float64{}
+	for tag, color := range opts.Colors {
+		colors[tag] = color
+	}
+	nodes := node.Nodes()
+	faces2 := nodes.triangulate()
+
+	// find all the unique uv coordinates
+	uvIndices := make(map[[2]float64]int)
+	if opts.Deterministic {
+		for _, n := range nodes {
+			for _, vertex := range n.Outer.Vertices {
+				uvCoord := [2]float64{vertex.U, vertex.V}
+				if _, seen := uvIndices[uvCoord]; !seen {
+					uvIndices[uvCoord] = len(uvIndices) + 1
+				}
+			}
+		}
+	} else {
+		uniqueUVs := make(map[[2]float64]int)
+		for _, n := range nodes {
+			for _, vertex := range n.Outer.Vertices {
+				uniqueUVs[[2]float64{vertex.U, vertex.V}] = 1
+			}
+		}
+		for uvCoord := range uniqueUVs {
+			uvIndices[uvCoord] = len(uvIndices) + 1
+		}
+	}
+
+	f := newErrWriter(obj)
+
+	// Create a map to store unique vertices and their indices
+	vertexIndices := make(map[[3]float64]int)
+	currentIndex := 1
+
+	// Write header
+	f.printf("mtllib %s\n", mtlName)
+	colors["default"] = [3]float64{1.0, 1.0, 1.0}
+
+	// Write triangles
+	for _, face := range faces2 {
+		for _, triangleArray := range face {
+			for i := 0; i < len(triangleArray); i += 3 {
+				// If the vertex hasn't been seen before, write it and store its index
+				key := [3]float64{triangleArray[i], triangleArray[i+1], triangleArray[i+2]}
+				if _, seen := vertexIndices[key]; !seen {
+					f.printf("v %s %s %s\n", opts.float(key[0]), opts.float(key[1]), opts.float(key[2]))
+					vertexIndices[key] = currentIndex
+					currentIndex++
+				}
+			}
+		}
+	}
+
+	// Organise triangles by tag
+	type TypedTag struct {
+		ImageTexture bool
+		Path         string
+		Index        int
+	}
+	trianglesByTag := make(map[string][][]float64)
+	nodeIndicesByTag := make(map[string][]int)
+	metaTag := make(map[string]TypedTag)
+	normalIndices := make(map[int]int)
+	for i, face := range faces2 {
+		tag := nodes[i].Tag
+		if tag == "" {
+			tag = "default"
+		}
+		imageTexture := nodes[i].ImageTexture
+		if imageTexture {
+			path := nodes[i].Tag
+			tag := path
+			metaTag[tag] = TypedTag{
+				ImageTexture: imageTexture,
+				Path:         path,
+				Index:        i,
+			}
+		}
+		for _, triangleArray := range face {
+			trianglesByTag[tag] = append(trianglesByTag[tag], triangleArray)
+			nodeIndicesByTag[tag] = append(nodeIndicesByTag[tag], i)
+		}
+
+		// Write normal
+		normal := nodes[i].Outer.Normal()
+		f.printf("vn %s %s %s\n", opts.float(normal.X), opts.float(normal.Y), opts.float(normal.Z))
+		normalIndices[i] = i + 1
+	}
+
+	// Write texture coordinates
+	sortedUvIndices := make([][2]float64, len(uvIndices))
+	for uvCoord, index := range uvIndices {
+		sortedUvIndices[index-1] = uvCoord
+	}
+	for _, uvCoord := range sortedUvIndices {
+		f.printf("vt %s %s\n", opts.float(uvCoord[0]), opts.float(uvCoord[1]))
+	}
+
+	tags := make([]string, 0, len(trianglesByTag))
+	for tag := range trianglesByTag {
+		tags = append(tags, tag)
+	}
+	if opts.Deterministic {
+		sort.Strings(tags)
+	}
+
+	// Write faces
+	for _, tag := range tags {
+		triangles := trianglesByTag[tag]
+		f.printf("usemtl %s\n", tag)
+		for t_i, triangleArray := range triangles {
+			f.printf("f")
+			for i := 0; i < len(triangleArray); i += 3 {
+				key := [3]float64{triangleArray[i], triangleArray[i+1], triangleArray[i+2]}
+
+				// check if the vertex has a uv coordinate
+				uvIndex := -1
+				meta := metaTag[tag]
+				if meta.ImageTexture {
+					for _, idx := range nodeIndicesByTag[tag] {
+						for _, n := range nodes[idx].Outer.Vertices {
+							if n.X == key[0] && n.Y == key[1] && n.Z == key[2] {
+								uvIndex = uvIndices[[2]float64{n.U, n.V}]
+								break
+							}
+						}
+					}
+				}
+
+				// Write vertex, texture, and normal indices
+				normalIndex := normalIndices[nodeIndicesByTag[tag][t_i]]
+				if uvIndex != -1 {
+					f.printf(" %d/%d/%d", vertexIndices[key], uvIndex, normalIndex)
+				} else {
+					f.printf(" %d//%d", vertexIndices[key], normalIndex)
+				}
+			}
+			f.printf("\n")
+		}
+	}
+	if err := f.flush(); err != nil {
+		return err
+	}
+
+	// Write materials
+	m := newErrWriter(mtl)
+	for _, tag := range tags {
+		m.printf("newmtl %s\n", tag)
+		meta := metaTag[tag]
+		if meta.ImageTexture {
+			m.printf("map_Kd %s\n", meta.Path)
+		} else {
+			color := colors[tag]
+			m.printf("Kd %s %s %s\n", opts.float(color[0]), opts.float(color[1]), opts.float(color[2]))
+		}
+	}
+	return m.flush()
+}
+
+// GenerateColor writes the node chain to the obj file name, with its
+// materials in an mtl file of the same name next to it
+func (node *Node) GenerateColor(name string, _colors ...map[string][3]float64) error {
+	opts := ObjOptions{}
+	if len(_colors) > 0 {
+		opts.Colors = _colors[0]
+	}
+	return node.GenerateColorWithOptions(name, opts)
+}
+
+// GenerateColorWithOptions is GenerateColor with control over the
+// ordering and number formatting of the output
+func (node *Node) GenerateColorWithOptions(name string, opts ObjOptions) error {
+	// remove .obj from name
+	mtlFilename := name[:len(name)-4] + ".mtl"
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	m, err := os.Create(mtlFilename)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	if err := node.WriteColorWithOptions(f, m, mtlFilename, opts); err != nil {
+		return err
+	}
+	if err := m.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// ReadOBJ parses an OBJ mesh into a linked node chain and returns its
+// first node. Every face becomes a node whose vertices are shared with
+// the other faces that use the same "v" index. The tag of each node is
+// the active "usemtl" material, or the active "g" group when there is
+// no material. A vertex used with several "vt" coordinates keeps the
+// first one. Faces keep the file's winding, so their normals point out
+// of the model the same as the ones the writers produce. Since Extrude
+// goes against the normal, call Flip on a face before extruding it to
+// grow the model outwards rather than into it
+func ReadOBJ(r io.Reader) (*Node, error) {
+	var vertices []*Vertex3D
+	var uvs [][2]float64
+	hasUV := map[*Vertex3D]bool{}
+	var material, group string
+	var nodes Nodes
+
+	// resolve turns a 1-based, possibly negative, obj index into a slice index
+	resolve := func(s string, count int) (int, error) {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, err
+		}
+		if i < 0 {
+			i += count
+		} else {
+			i--
+		}
+		if i < 0 || i >= count {
+			return 0, fmt.Errorf("index %s out of range", s)
+		}
+		return i, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		fail := func(err error) (*Node, error) {
+			return nil, fmt.Errorf("obj line %d: %v", lineNumber, err)
+		}
+		switch fields[0] {
+		case "v":
+			if len(fields) < 4 {
+				return fail(fmt.Errorf("vertex needs 3 coordinates"))
+			}
+			var xyz [3]float64
+			for i := range xyz {
+				value, err := strconv.ParseFloat(fields[i+1], 64)
+				if err != nil {
+					return fail(err)
+				}
+				xyz[i] = value
+			}
+			vertices = append(vertices, NewVertex3D(xyz[0], xyz[1], xyz[2]))
+		case "vt":
+			if len(fields) < 2 {
+				return fail(fmt.Errorf("texture coordinate needs a value"))
+			}
+			var uv [2]float64
+			for i := 0; i < 2 && i+1 < len(fields); i++ {
+				value, err := strconv.ParseFloat(fields[i+1], 64)
+				if err != nil {
+					return fail(err)
+				}
+				uv[i] = value
+			}
+			uvs = append(uvs, uv)
+		case "usemtl":
+			material = strings.Join(fields[1:], " ")
+		case "g", "o":
+			if fields[0] == "g" || group == "" {
+				group = strings.Join(fields[1:], " ")
+			}
+		case "f":
+			if len(fields) < 4 {
+				return fail(fmt.Errorf("face needs at least 3 vertices"))
+			}
+			face := &Face3D{}
+			for _, field := range fields[1:] {
+				parts := strings.Split(field, "/")
+				vi, err := resolve(parts[0], len(vertices))
+				if err != nil {
+					return fail(err)
+				}
+				vertex := vertices[vi]
+				if len(parts) > 1 && parts[1] != "" {
+					ti, err := resolve(parts[1], len(uvs))
+					if err != nil {
+						return fail(err)
+					}
+					if !hasUV[vertex] {
+						vertex.UV(uvs[ti][0], uvs[ti][1])
+						hasUV[vertex] = true
+					}
+				}
+				face.Vertices = append(face.Vertices, vertex)
+			}
+			tag := material
+			if tag == "" {
+				tag = group
+			}
+			nodes = append(nodes, NewTaggedNode(tag, face))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("obj has no faces")
+	}
+	nodes.LinkNodes()
+	return nodes[0], nil
+}
+
+// LoadOBJ reads an OBJ file, see ReadOBJ
+func LoadOBJ(filename string) (*Node, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadOBJ(f)
+}
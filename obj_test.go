@@ -0,0 +1,186 @@
+package toothpaste
+
+import (
+	"bytes"
+	"github.com/micah5/earcut-3d"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteOBJ(t *testing.T) {
+	node := testCube()
+	var buf bytes.Buffer
+	if err := node.WriteOBJ(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// should match what earcut3d writes for the same triangles
+	var faces [][]float64
+	for _, n := range node.Nodes() {
+		faces = append(faces, n.Outer.Flatten())
+	}
+	filename := filepath.Join(t.TempDir(), "cube.obj")
+	earcut3d.CreateObjFile(filename, earcut3d.Earcut(faces))
+	expected, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != string(expected) {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWriteColor(t *testing.T) {
+	node := testCube()
+	node.TagAll("red")
+	var obj, mtl bytes.Buffer
+	err := node.WriteColor(&obj, &mtl, "cube.mtl", map[string][3]float64{
+		"red": {1, 0, 0},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(obj.String(), "mtllib cube.mtl\n") {
+		t.Errorf("Expected mtllib header, got %q", obj.String())
+	}
+	if c := strings.Count(obj.String(), "\nf "); c != 12 {
+		t.Errorf("Expected 12 faces, got %v", c)
+	}
+	if mtl.String() != "newmtl red\nKd 1.000000 0.000000 0.000000\n" {
+		t.Errorf("Unexpected mtl %q", mtl.String())
+	}
+}
+
+func TestGenerateColorError(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "missing", "cube.obj")
+	if err := testCube().GenerateColor(filename); err == nil {
+		t.Errorf("Expected an error writing to %v", filename)
+	}
+}
+
+func TestWriteColorDeterministic(t *testing.T) {
+	write := func() (string, string) {
+		node := testCube()
+		for i, n := range node.Nodes() {
+			n.Tag = []string{"c", "a", "b"}[i%3]
+		}
+		var obj, mtl bytes.Buffer
+		shortest := -1
+		err := node.WriteColorWithOptions(&obj, &mtl, "cube.mtl", ObjOptions{
+			Deterministic: true,
+			Precision:     &shortest,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return obj.String(), mtl.String()
+	}
+	obj, mtl := write()
+	for i := 0; i < 10; i++ {
+		obj2, mtl2 := write()
+		if obj != obj2 || mtl != mtl2 {
+			t.Fatalf("Expected identical output on every run")
+		}
+	}
+	if !strings.HasPrefix(mtl, "newmtl a\nKd 0 0 0\nnewmtl b\n") {
+		t.Errorf("Expected materials sorted by tag, got %q", mtl)
+	}
+	if !strings.Contains(obj, "\nv 1 0 0\n") {
+		t.Errorf("Expected shortest float formatting, got %q", obj)
+	}
+
+	var obj0, mtl0 bytes.Buffer
+	whole := 0
+	if err := testCube().WriteColorWithOptions(&obj0, &mtl0, "cube.mtl", ObjOptions{Precision: &whole}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(obj0.String(), "\nv 1 0 0\n") {
+		t.Errorf("Expected whole numbers with precision 0, got %q", obj0.String())
+	}
+}
+
+const testCubeOBJ = `# cube
+v 0 0 0
+v 1 0 0
+v 1 0 1
+v 0 0 1
+v 0 1 0
+v 1 1 0
+v 1 1 1
+v 0 1 1
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+g box
+f 1/1 2/2 3/3 4/4
+f 5 8 7 6
+usemtl side
+f 1 5 6 2
+f 2 6 7 3
+f 3 7 8 4
+f -5 -1 -4 -8
+`
+
+func TestReadOBJ(t *testing.T) {
+	node, err := ReadOBJ(strings.NewReader(testCubeOBJ))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := node.Nodes()
+	if len(nodes) != 6 {
+		t.Fatalf("Expected 6 nodes, got %v", len(nodes))
+	}
+	if len(nodes.UniqueVertices()) != 8 {
+		t.Errorf("Expected 8 shared vertices, got %v", len(nodes.UniqueVertices()))
+	}
+	if nodes[0].Tag != "box" || nodes[5].Tag != "side" {
+		t.Errorf("Expected tags box and side, got %v and %v", nodes[0].Tag, nodes[5].Tag)
+	}
+	if v := nodes[0].Outer.Vertices[2]; v.U != 1 || v.V != 1 {
+		t.Errorf("Expected uv of 1, 1, got %v, %v", v.U, v.V)
+	}
+	if nodes[5].Outer.Vertices[0] != nodes[0].Outer.Vertices[3] {
+		t.Errorf("Expected negative indices to resolve to the shared vertex")
+	}
+	if connected := nodes[0].Connected(); len(connected) != 4 {
+		t.Errorf("Expected 4 connected nodes, got %v", len(connected))
+	}
+
+	// faces point out of the model, so are flipped to extrude outwards
+	if normal := nodes[1].Outer.Normal(); normal.Y < 0.99 {
+		t.Errorf("Expected the top to face up, got %v", normal)
+	}
+	nodes[1].Flip()
+	top := nodes[1].ExtrudeDrop(1)
+	if len(top.Nodes()) != 10 {
+		t.Errorf("Expected 10 nodes after extruding, got %v", len(top.Nodes()))
+	}
+	if y := top.Outer.Centroid().Y; y != 2 {
+		t.Errorf("Expected the new top at y=2, got %v", y)
+	}
+	for _, side := range top.Nodes() {
+		centre := side.Outer.Centroid()
+		if centre.Y != 1.5 {
+			continue
+		}
+		if side.Outer.Normal().Dot(centre.Subtract(NewVertex3D(0.5, 1.5, 0.5))) <= 0 {
+			t.Errorf("Expected the new sides to face out, got %v", side.Outer.Normal())
+		}
+	}
+}
+
+func TestReadOBJErrors(t *testing.T) {
+	for _, obj := range []string{
+		"v 0 0 0\nf 1 2 3\n",
+		"v 0 0\n",
+		"v 0 0 0\nv 1 0 0\nf 1 2\n",
+		"",
+	} {
+		if _, err := ReadOBJ(strings.NewReader(obj)); err == nil {
+			t.Errorf("Expected an error reading %q", obj)
+		}
+	}
+}
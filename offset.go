@@ -0,0 +1,138 @@
+package toothpaste
+
+import (
+	"math"
+)
+
+type JoinType int
+
+const (
+	JoinMiter JoinType = iota
+	JoinRound
+	JoinBevel
+)
+
+type OffsetOptions struct {
+	MiterLimit   float64 // longest miter as a multiple of the distance, defaults to 2
+	ArcTolerance float64 // furthest round joins stray from a true arc, defaults to 1% of the distance
+}
+
+// Offset moves every edge outwards by distance (inwards if negative) and
+// joins them back up, so the new outline is the same distance from the
+// original all the way round. Corners that open up are filled with the
+// join, and parts too thin to survive an inset disappear, which can
+// split the face into several shapes
+func (f *Face2D) Offset(distance float64, join JoinType, opts ...OffsetOptions) []*Shape2D {
+	outer := f.Copy()
+	if signedArea(outer.Vertices) < 0 {
+		reverseVertices(outer.Vertices)
+	}
+	return NewShape2D(outer).Offset(distance, join, opts...)
+}
+
+// Offset grows the shape by distance, shrinking its holes, or shrinks it
+// if distance is negative. See Face2D.Offset
+func (s *Shape2D) Offset(distance float64, join JoinType, opts ...OffsetOptions) []*Shape2D {
+	var opt OffsetOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.MiterLimit <= 0 {
+		opt.MiterLimit = 2
+	}
+	if opt.ArcTolerance <= 0 {
+		opt.ArcTolerance = math.Abs(distance) / 100
+	}
+	if distance == 0 {
+		return []*Shape2D{s.Copy()}
+	}
+
+	var rings []*Face2D
+	for i, face := range s.Faces() {
+		vertices := cleanRing(face.Vertices, 0)
+		if len(vertices) < 3 {
+			continue
+		}
+		// outers counter-clockwise and holes clockwise, so the right
+		// hand side of every edge faces away from the shape
+		area := signedArea(vertices)
+		if (i == 0) != (area > 0) {
+			vertices = append([]*Vertex2D{}, vertices...)
+			reverseVertices(vertices)
+		}
+		rings = append(rings, &Face2D{Vertices: offsetRing(vertices, distance, join, opt)})
+	}
+	// the raw outline loops over itself where corners overlap, but those
+	// parts wind the wrong way, so the positive winding rule removes them
+	inside := func(x, y float64) bool {
+		winding := 0
+		for _, ring := range rings {
+			winding += windingNumber(x, y, ring.Vertices)
+		}
+		return winding > 0
+	}
+	return fillRings(rings, inside)
+}
+
+// offsetRing builds the raw offset outline of a ring, before any self
+// intersections are resolved
+func offsetRing(vertices []*Vertex2D, distance float64, join JoinType, opt OffsetOptions) []*Vertex2D {
+	n := len(vertices)
+	// unit normals on the right of each edge
+	normals := make([][2]float64, n)
+	for i, v := range vertices {
+		next := vertices[(i+1)%n]
+		length := v.Distance(next)
+		normals[i] = [2]float64{(next.Y - v.Y) / length, -(next.X - v.X) / length}
+	}
+
+	var res []*Vertex2D
+	add := func(v *Vertex2D, normal [2]float64, d float64) {
+		res = append(res, NewVertex2D(v.X+normal[0]*d, v.Y+normal[1]*d))
+	}
+	for i, v := range vertices {
+		n1, n2 := normals[(i+n-1)%n], normals[i]
+		// cross of the edge directions, positive for a left turn
+		cross := n1[0]*n2[1] - n1[1]*n2[0]
+		dot := n1[0]*n2[0] + n1[1]*n2[1]
+		if math.Abs(cross) < 1e-9 && dot > 0 {
+			add(v, n1, distance)
+			continue
+		}
+		if cross*distance <= 0 {
+			// the offset edges overlap here, going via the original
+			// vertex makes a loop that the winding rule removes
+			add(v, n1, distance)
+			res = append(res, v.Copy())
+			add(v, n2, distance)
+			continue
+		}
+
+		switch join {
+		case JoinMiter:
+			if math.Sqrt(2/(1+dot)) <= opt.MiterLimit {
+				scale := distance / (1 + dot)
+				res = append(res, NewVertex2D(v.X+(n1[0]+n2[0])*scale, v.Y+(n1[1]+n2[1])*scale))
+				continue
+			}
+			add(v, n1, distance)
+			add(v, n2, distance)
+		case JoinRound:
+			radius := math.Abs(distance)
+			start := math.Atan2(n1[1]*distance, n1[0]*distance)
+			sweep := math.Atan2(cross, dot)
+			steps := 1
+			if opt.ArcTolerance < radius {
+				steps = int(math.Ceil(math.Abs(sweep) / (2 * math.Acos(1-opt.ArcTolerance/radius))))
+			}
+			for step := 0; step <= steps; step++ {
+				angle := start + sweep*float64(step)/float64(steps)
+				res = append(res, NewVertex2D(v.X+math.Cos(angle)*radius, v.Y+math.Sin(angle)*radius))
+			}
+		default:
+			add(v, n1, distance)
+			add(v, n2, distance)
+		}
+	}
+	return res
+}
@@ -0,0 +1,63 @@
+package toothpaste
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOffsetJoins(t *testing.T) {
+	for _, test := range []struct {
+		join JoinType
+		area float64
+	}{
+		{JoinMiter, 16},
+		{JoinBevel, 14},
+		{JoinRound, 12 + math.Pi},
+	} {
+		shapes := Square(2, 2).Offset(1, test.join, OffsetOptions{ArcTolerance: 1e-4})
+		if len(shapes) != 1 {
+			t.Fatalf("Expected 1 shape, got %v", len(shapes))
+		}
+		if area := shapesArea(shapes); math.Abs(area-test.area) > 1e-2 {
+			t.Errorf("Expected join %v to give area %v, got %v", test.join, test.area, area)
+		}
+	}
+
+	// a miter past the limit is bevelled
+	shapes := Square(2, 2).Offset(1, JoinMiter, OffsetOptions{MiterLimit: 1.2})
+	if area := shapesArea(shapes); math.Abs(area-14) > 1e-9 {
+		t.Errorf("Expected area 14, got %v", area)
+	}
+}
+
+func TestOffsetInset(t *testing.T) {
+	// an L shape keeps its shape and constant wall thickness
+	l := NewFace2D(0, 0, 2, 0, 2, 1, 1, 1, 1, 2, 0, 2)
+	shapes := l.Offset(-0.25, JoinMiter)
+	if len(shapes) != 1 || len(shapes[0].Outer.Vertices) != 6 {
+		t.Fatalf("Expected an L with 6 corners, got %v", shapes)
+	}
+	if area := shapesArea(shapes); math.Abs(area-1.25) > 1e-9 {
+		t.Errorf("Expected area 1.25, got %v", area)
+	}
+
+	// insetting past the thin bar splits the dumbbell in two
+	dumbbell := NewFace2D(0, 0, 1, 0, 1, 0.45, 2, 0.45, 2, 0, 3, 0, 3, 1, 2, 1, 2, 0.55, 1, 0.55, 1, 1, 0, 1)
+	if n := len(dumbbell.Offset(-0.1, JoinMiter)); n != 2 {
+		t.Errorf("Expected 2 shapes, got %v", n)
+	}
+	if n := len(Square(2, 2).Offset(-1.5, JoinMiter)); n != 0 {
+		t.Errorf("Expected the square to disappear, got %v shapes", n)
+	}
+
+	// holes shrink as the shape grows
+	hole := Square(2, 2)
+	hole.Translate(1, 1)
+	shapes = NewShape2D(Square(4, 4), hole).Offset(0.5, JoinMiter)
+	if len(shapes) != 1 || len(shapes[0].Holes) != 1 {
+		t.Fatalf("Expected 1 shape with a hole, got %v", shapes)
+	}
+	if area := shapesArea(shapes); math.Abs(area-24) > 1e-9 {
+		t.Errorf("Expected area 24, got %v", area)
+	}
+}
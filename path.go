@@ -0,0 +1,285 @@
+package toothpaste
+
+import (
+	"math"
+)
+
+type pathCommand int
+
+const (
+	pathLine pathCommand = iota
+	pathQuad
+	pathCubic
+	pathArc
+	pathSpline
+)
+
+// pathSegment runs from the end of the previous segment through its
+// control points to the last of points
+type pathSegment struct {
+	command          pathCommand
+	points           []*Vertex2D
+	rx, ry, rotation float64
+	largeArc, sweep  bool
+}
+
+type subpath struct {
+	start    *Vertex2D
+	segments []pathSegment
+}
+
+// Path describes an outline with lines and curves, built up a piece at a
+// time like a pen moving over paper. It keeps the curves, so the same
+// path can be flattened into faces at different resolutions
+type Path struct {
+	subpaths []*subpath
+	cur      *Vertex2D
+	open     bool
+}
+
+func NewPath() *Path {
+	return &Path{cur: NewVertex2D(0, 0)}
+}
+
+// MoveTo starts a new outline at x, y
+func (p *Path) MoveTo(x, y float64) *Path {
+	p.cur = NewVertex2D(x, y)
+	p.subpaths = append(p.subpaths, &subpath{start: p.cur.Copy()})
+	p.open = true
+	return p
+}
+
+func (p *Path) add(segment pathSegment) *Path {
+	if !p.open {
+		// drawing after a close carries on from where it closed
+		p.MoveTo(p.cur.X, p.cur.Y)
+	}
+	sub := p.subpaths[len(p.subpaths)-1]
+	sub.segments = append(sub.segments, segment)
+	p.cur = segment.points[len(segment.points)-1].Copy()
+	return p
+}
+
+func (p *Path) LineTo(x, y float64) *Path {
+	return p.add(pathSegment{command: pathLine, points: []*Vertex2D{NewVertex2D(x, y)}})
+}
+
+// QuadTo draws a quadratic bezier curve with control point cx, cy
+func (p *Path) QuadTo(cx, cy, x, y float64) *Path {
+	return p.add(pathSegment{command: pathQuad, points: []*Vertex2D{NewVertex2D(cx, cy), NewVertex2D(x, y)}})
+}
+
+// CubicTo draws a cubic bezier curve with control points c1 and c2
+func (p *Path) CubicTo(c1x, c1y, c2x, c2y, x, y float64) *Path {
+	return p.add(pathSegment{command: pathCubic, points: []*Vertex2D{
+		NewVertex2D(c1x, c1y), NewVertex2D(c2x, c2y), NewVertex2D(x, y),
+	}})
+}
+
+// ArcTo draws part of an ellipse with radii rx and ry, rotated by
+// rotation degrees, to x, y. Like svg, of the four arcs that fit,
+// largeArc picks the longer way round and sweep the counter-clockwise
+// one (clockwise once y is flipped to point down). Radii too small to
+// reach are scaled up
+func (p *Path) ArcTo(rx, ry, rotation float64, largeArc, sweep bool, x, y float64) *Path {
+	return p.add(pathSegment{
+		command:  pathArc,
+		points:   []*Vertex2D{NewVertex2D(x, y)},
+		rx:       rx,
+		ry:       ry,
+		rotation: rotation,
+		largeArc: largeArc,
+		sweep:    sweep,
+	})
+}
+
+// SplineTo draws a cubic B-spline starting at the current point and
+// using the given x, y pairs as control points. It is clamped, so it
+// ends exactly on the last one
+func (p *Path) SplineTo(points ...float64) *Path {
+	segment := pathSegment{command: pathSpline}
+	for i := 0; i+1 < len(points); i += 2 {
+		segment.points = append(segment.points, NewVertex2D(points[i], points[i+1]))
+	}
+	if len(segment.points) == 0 {
+		return p
+	}
+	return p.add(segment)
+}
+
+// Close ends the current outline, joining it back to its start
+func (p *Path) Close() *Path {
+	if p.open {
+		p.cur = p.subpaths[len(p.subpaths)-1].start.Copy()
+		p.open = false
+	}
+	return p
+}
+
+// Face flattens the first outline of the path so that curves stay within
+// tolerance of the true curve. However small the tolerance, a curve is
+// never split into more than 1024 segments
+func (p *Path) Face(tolerance float64) *Face2D {
+	return p.face(tolerance, 0)
+}
+
+// FaceSegments flattens the first outline of the path, splitting every
+// curve into the same number of segments (at least 1)
+func (p *Path) FaceSegments(segments int) *Face2D {
+	if segments < 1 {
+		segments = 1
+	}
+	return p.face(0, segments)
+}
+
+func (p *Path) face(tolerance float64, segments int) *Face2D {
+	rings := p.rings(tolerance, segments)
+	if len(rings) == 0 {
+		return &Face2D{}
+	}
+	return &Face2D{Vertices: rings[0]}
+}
+
+// Shapes flattens all the outlines of the path, with the tolerance
+// used the same way as Face, and sorts them into shapes with holes by
+// how they nest
+func (p *Path) Shapes(tolerance float64) []*Shape2D {
+	var faces []*Face2D
+	for _, ring := range p.rings(tolerance, 0) {
+		faces = append(faces, &Face2D{Vertices: ring})
+	}
+	return shapesFromRings(faces)
+}
+
+// rings flattens each outline, by tolerance if segments is 0
+func (p *Path) rings(tolerance float64, segments int) [][]*Vertex2D {
+	var rings [][]*Vertex2D
+	for _, sub := range p.subpaths {
+		ring := []*Vertex2D{sub.start.Copy()}
+		cur := sub.start
+		for _, segment := range sub.segments {
+			ring = append(ring, segment.flatten(cur, tolerance, segments)...)
+			cur = segment.points[len(segment.points)-1]
+		}
+		if ring = cleanRing(ring, 0); len(ring) > 0 {
+			rings = append(rings, ring)
+		}
+	}
+	return rings
+}
+
+func (s pathSegment) flatten(start *Vertex2D, tolerance float64, segments int) []*Vertex2D {
+	end := s.points[len(s.points)-1]
+	count := func(n int) int {
+		if segments > 0 {
+			return segments
+		}
+		return n
+	}
+	switch s.command {
+	case pathQuad:
+		n := count(quadSegments(start, s.points[0], end, tolerance))
+		return flattenQuad(start, s.points[0], end, n)
+	case pathCubic:
+		n := count(cubicSegments(start, s.points[0], s.points[1], end, tolerance))
+		return flattenCubic(start, s.points[0], s.points[1], end, n)
+	case pathArc:
+		arc := endpointArc(start.X, start.Y, s.rx, s.ry, s.rotation, s.largeArc, s.sweep, end.X, end.Y)
+		if arc.degenerate {
+			return []*Vertex2D{end.Copy()}
+		}
+		points := arc.flatten(count(arc.segments(tolerance)))
+		// land exactly on the end point
+		points[len(points)-1] = end.Copy()
+		return points
+	case pathSpline:
+		return flattenSpline(append([]*Vertex2D{start}, s.points...), tolerance, segments)
+	}
+	return []*Vertex2D{end.Copy()}
+}
+
+// bspline is a clamped uniform B-spline over n control points, cubic
+// or a lower degree if there are too few of them
+type bspline struct {
+	degree, spans int
+	knots         []float64
+}
+
+// newBSpline returns nil if there are too few control points for a curve
+func newBSpline(n int) *bspline {
+	degree := 3
+	if n <= degree {
+		degree = n - 1
+	}
+	if degree < 1 {
+		return nil
+	}
+	// clamped knots: degree+1 zeros, the inner knots, degree+1 ends
+	spans := n - degree
+	knots := make([]float64, n+degree+1)
+	for i := range knots {
+		knots[i] = math.Max(0, math.Min(float64(spans), float64(i-degree)))
+	}
+	return &bspline{degree: degree, spans: spans, knots: knots}
+}
+
+// weights evaluates the knot span with de Boor's algorithm at t, from
+// span-degree to span-degree+1, giving how much each of the control
+// points span-degree to span counts towards the point there
+func (b *bspline) weights(span int, t float64) []float64 {
+	d := make([][]float64, b.degree+1)
+	for j := range d {
+		d[j] = make([]float64, b.degree+1)
+		d[j][j] = 1
+	}
+	for r := 1; r <= b.degree; r++ {
+		for j := b.degree; j >= r; j-- {
+			i := span - b.degree + j
+			alpha := (t - b.knots[i]) / (b.knots[i+b.degree+1-r] - b.knots[i])
+			for k := range d[j] {
+				d[j][k] = (1-alpha)*d[j-1][k] + alpha*d[j][k]
+			}
+		}
+	}
+	return d[b.degree]
+}
+
+// flattenSpline evaluates a clamped uniform cubic B-spline (or a lower
+// degree one if there are too few control points), one knot span at a
+// time
+func flattenSpline(controls []*Vertex2D, tolerance float64, segments int) []*Vertex2D {
+	spline := newBSpline(len(controls))
+	if spline == nil {
+		return nil
+	}
+	degree, spans := spline.degree, spline.spans
+	eval := func(span int, t float64) *Vertex2D {
+		point := NewVertex2D(0, 0)
+		for j, w := range spline.weights(span, t) {
+			c := controls[span-degree+j]
+			point.X += w * c.X
+			point.Y += w * c.Y
+		}
+		return point
+	}
+
+	var points []*Vertex2D
+	for s := 0; s < spans; s++ {
+		span := s + degree
+		n := segments
+		if n <= 0 {
+			// the control points of the span bound its curvature much
+			// like a bezier's do
+			local := controls[span-degree : span+1]
+			n = 1
+			for i := 0; i+2 < len(local); i++ {
+				n = int(math.Max(float64(n), float64(quadSegments(local[i], local[i+1], local[i+2], tolerance))))
+			}
+		}
+		for i := 1; i <= n; i++ {
+			points = append(points, eval(span, float64(s)+float64(i)/float64(n)))
+		}
+	}
+	points[len(points)-1] = controls[len(controls)-1].Copy()
+	return points
+}
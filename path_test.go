@@ -0,0 +1,72 @@
+package toothpaste
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPathFlatten(t *testing.T) {
+	// a unit circle drawn as two half arcs
+	circle := NewPath().MoveTo(1, 0).ArcTo(1, 1, 0, false, true, -1, 0).ArcTo(1, 1, 0, false, true, 1, 0).Close()
+
+	coarse, fine := circle.Face(0.1), circle.Face(0.0001)
+	if len(coarse.Vertices) >= len(fine.Vertices) {
+		t.Errorf("Expected a finer tolerance to give more vertices, got %v and %v", len(coarse.Vertices), len(fine.Vertices))
+	}
+	if area := fine.Area(); math.Abs(area-math.Pi) > 1e-3 {
+		t.Errorf("Expected area pi, got %v", area)
+	}
+	if fine.IsClockwise() {
+		t.Errorf("Expected a sweeping arc to run counter-clockwise")
+	}
+	if n := len(circle.FaceSegments(8).Vertices); n != 16 {
+		t.Errorf("Expected 16 vertices, got %v", n)
+	}
+
+	// control points outside the shape pull the curves outwards
+	p := NewPath().MoveTo(0, 0).LineTo(2, 0).QuadTo(3, 1, 2, 2).CubicTo(1.5, 3, 0.5, 3, 0, 2).Close()
+	f := p.Face(0.001)
+	if err := f.Validate(); err != nil {
+		t.Errorf("Expected a valid face, got %v", err)
+	}
+	if f.Area() <= 4 {
+		t.Errorf("Expected the curves to add area, got %v", f.Area())
+	}
+
+	// no tolerance at all still gives a bounded number of segments
+	for _, tolerance := range []float64{0, -1} {
+		if n := len(circle.Face(tolerance).Vertices); n != 2*maxCurveSegments {
+			t.Errorf("Expected %v vertices with tolerance %v, got %v", 2*maxCurveSegments, tolerance, n)
+		}
+		if n := len(p.Face(tolerance).Vertices); n != 2+2*maxCurveSegments {
+			t.Errorf("Expected %v vertices with tolerance %v, got %v", 2+2*maxCurveSegments, tolerance, n)
+		}
+		if shapes := p.Shapes(tolerance); len(shapes) != 1 {
+			t.Errorf("Expected 1 shape with tolerance %v, got %v", tolerance, len(shapes))
+		}
+	}
+	if n := len(circle.FaceSegments(0).Vertices); n != 2 {
+		t.Errorf("Expected 2 vertices with 0 segments, got %v", n)
+	}
+}
+
+func TestPathSpline(t *testing.T) {
+	f := NewPath().MoveTo(0, 0).SplineTo(0, 2, 2, 2, 2, 0).Close().FaceSegments(10)
+	last := f.Vertices[len(f.Vertices)-1]
+	if last.X != 2 || last.Y != 0 {
+		t.Errorf("Expected the spline to end on its last control point, got %v", last)
+	}
+	for _, v := range f.Vertices {
+		if v.X < 0 || v.X > 2 || v.Y < 0 || v.Y > 2 {
+			t.Errorf("Expected the spline to stay in the hull of its control points, got %v", v)
+		}
+	}
+
+	// two outlines, one inside the other
+	p := NewPath().MoveTo(0, 0).LineTo(4, 0).LineTo(4, 4).LineTo(0, 4).Close()
+	p.MoveTo(1, 1).LineTo(3, 1).LineTo(3, 3).LineTo(1, 3).Close()
+	shapes := p.Shapes(0.01)
+	if len(shapes) != 1 || len(shapes[0].Holes) != 1 {
+		t.Errorf("Expected 1 shape with a hole, got %v", shapes)
+	}
+}
@@ -0,0 +1,283 @@
+package toothpaste
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// PLYOptions controls the output of WritePLY
+type PLYOptions struct {
+	// Binary writes binary_little_endian instead of ascii
+	Binary bool
+	// Triangulate writes every face as triangles. Faces with holes
+	// are always triangulated since PLY polygons can't have holes
+	Triangulate bool
+	// Colors maps tags to per-vertex colors. Vertices are only
+	// colored when this is set, tags without a color are white
+	Colors map[string][3]float64
+}
+
+type plyVertex struct {
+	Position [3]float64
+	Color    [3]uint8
+}
+
+type plyFace struct {
+	Indices []int
+	Tag     int
+	Meta    []float64
+}
+
+type plyMesh struct {
+	Vertices  []plyVertex
+	Faces     []plyFace
+	Tags      []string
+	MetaKeys  []string
+	MetaNames []string // the property each key is written as
+}
+
+// plyReserved are the property names toothpaste writes itself, which
+// meta keys can't be written as
+var plyReserved = map[string]bool{
+	"x": true, "y": true, "z": true, "nx": true, "ny": true, "nz": true,
+	"red": true, "green": true, "blue": true, "vertex_indices": true, "tag": true,
+}
+
+// plyMeta converts the numeric values that can be stored in a
+// PLY property
+func plyMeta(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func (n *Node) plyMesh(opts PLYOptions) *plyMesh {
+	nodes := n.Nodes()
+	mesh := &plyMesh{}
+
+	// every numeric meta key becomes a face property
+	keys := map[string]bool{}
+	for _, node := range nodes {
+		for key, value := range node.Meta {
+			if _, ok := plyMeta(value); ok {
+				keys[key] = true
+			}
+		}
+	}
+	for key := range keys {
+		mesh.MetaKeys = append(mesh.MetaKeys, key)
+	}
+	sort.Strings(mesh.MetaKeys)
+	// property names can't have spaces, and clashes with the built in
+	// properties or each other are renamed with a meta_ prefix
+	used := map[string]bool{}
+	for _, key := range mesh.MetaKeys {
+		name := strings.Join(strings.Fields(key), "_")
+		for name == "" || plyReserved[name] || used[name] {
+			name = "meta_" + name
+		}
+		used[name] = true
+		mesh.MetaNames = append(mesh.MetaNames, name)
+	}
+
+	tagIndices := map[string]int{}
+	vertexIndices := map[plyVertex]int{}
+	addVertex := func(v *Vertex3D, color [3]uint8) int {
+		key := plyVertex{Position: [3]float64{v.X, v.Y, v.Z}, Color: color}
+		index, seen := vertexIndices[key]
+		if !seen {
+			index = len(mesh.Vertices)
+			vertexIndices[key] = index
+			mesh.Vertices = append(mesh.Vertices, key)
+		}
+		return index
+	}
+
+	var triangles [][][3]*Vertex3D
+	for i, node := range nodes {
+		tag, ok := tagIndices[node.Tag]
+		if !ok {
+			tag = len(mesh.Tags)
+			tagIndices[node.Tag] = tag
+			mesh.Tags = append(mesh.Tags, node.Tag)
+		}
+		var color [3]uint8
+		if opts.Colors != nil {
+			c, ok := opts.Colors[node.Tag]
+			if !ok {
+				c = [3]float64{1, 1, 1}
+			}
+			for j := range c {
+				color[j] = uint8(math.Round(math.Max(0, math.Min(1, c[j])) * 255))
+			}
+		}
+		meta := make([]float64, len(mesh.MetaKeys))
+		for j, key := range mesh.MetaKeys {
+			meta[j], _ = plyMeta(node.Meta[key])
+		}
+
+		if !opts.Triangulate && len(node.Inner) == 0 {
+			face := plyFace{Tag: tag, Meta: meta}
+			for _, v := range node.Outer.Vertices {
+				face.Indices = append(face.Indices, addVertex(v, color))
+			}
+			mesh.Faces = append(mesh.Faces, face)
+			continue
+		}
+		if triangles == nil {
+			triangles = nodes.orientedTriangles()
+		}
+		for _, tri := range triangles[i] {
+			face := plyFace{Tag: tag, Meta: meta}
+			for _, v := range tri {
+				face.Indices = append(face.Indices, addVertex(v, color))
+			}
+			mesh.Faces = append(mesh.Faces, face)
+		}
+	}
+	return mesh
+}
+
+// WritePLY writes the node chain to w as a PLY mesh. The tag of each
+// face is written as an index into the tag list in the header comments,
+// and numeric metadata as one double property per key
+func (n *Node) WritePLY(w io.Writer, opts PLYOptions) error {
+	mesh := n.plyMesh(opts)
+	ew := newErrWriter(w)
+
+	// polygons with many vertices need a wider count
+	countType := "uchar"
+	for _, face := range mesh.Faces {
+		if len(face.Indices) > math.MaxUint8 {
+			countType = "uint"
+		}
+	}
+
+	format := "ascii"
+	if opts.Binary {
+		format = "binary_little_endian"
+	}
+	ew.printf("ply\nformat %s 1.0\ncomment generated by toothpaste\n", format)
+	for i, tag := range mesh.Tags {
+		ew.printf("comment tag %d %s\n", i, tag)
+	}
+	ew.printf("element vertex %d\n", len(mesh.Vertices))
+	ew.printf("property float x\nproperty float y\nproperty float z\n")
+	if opts.Colors != nil {
+		ew.printf("property uchar red\nproperty uchar green\nproperty uchar blue\n")
+	}
+	ew.printf("element face %d\n", len(mesh.Faces))
+	ew.printf("property list %s int vertex_indices\n", countType)
+	ew.printf("property int tag\n")
+	for _, name := range mesh.MetaNames {
+		ew.printf("property double %s\n", name)
+	}
+	ew.printf("end_header\n")
+
+	if opts.Binary {
+		if err := ew.flush(); err != nil {
+			return err
+		}
+		return writePLYBinary(w, mesh, opts.Colors != nil, countType)
+	}
+
+	for _, v := range mesh.Vertices {
+		ew.printf("%g %g %g", float32(v.Position[0]), float32(v.Position[1]), float32(v.Position[2]))
+		if opts.Colors != nil {
+			ew.printf(" %d %d %d", v.Color[0], v.Color[1], v.Color[2])
+		}
+		ew.printf("\n")
+	}
+	for _, face := range mesh.Faces {
+		ew.printf("%d", len(face.Indices))
+		for _, index := range face.Indices {
+			ew.printf(" %d", index)
+		}
+		ew.printf(" %d", face.Tag)
+		for _, value := range face.Meta {
+			ew.printf(" %g", value)
+		}
+		ew.printf("\n")
+	}
+	return ew.flush()
+}
+
+func writePLYBinary(w io.Writer, mesh *plyMesh, colors bool, countType string) error {
+	bw := bufio.NewWriter(w)
+	le := binary.LittleEndian
+	for _, v := range mesh.Vertices {
+		for _, p := range v.Position {
+			binary.Write(bw, le, float32(p))
+		}
+		if colors {
+			bw.Write(v.Color[:])
+		}
+	}
+	for _, face := range mesh.Faces {
+		if countType == "uchar" {
+			bw.WriteByte(uint8(len(face.Indices)))
+		} else {
+			binary.Write(bw, le, uint32(len(face.Indices)))
+		}
+		for _, index := range face.Indices {
+			binary.Write(bw, le, int32(index))
+		}
+		binary.Write(bw, le, int32(face.Tag))
+		for _, value := range face.Meta {
+			binary.Write(bw, le, value)
+		}
+	}
+	// bufio.Writer keeps the first error, so checking Flush is enough
+	return bw.Flush()
+}
+
+// GeneratePLY writes the node chain to a PLY file
+func (n *Node) GeneratePLY(filename string, opts ...PLYOptions) error {
+	_opts := PLYOptions{}
+	if len(opts) > 0 {
+		_opts = opts[0]
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := n.WritePLY(f, _opts); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,83 @@
+package toothpaste
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWritePLYASCII(t *testing.T) {
+	node := testCube()
+	node.TagAll("red")
+	node.Last().SetMeta("weight", 2)
+	var buf bytes.Buffer
+	err := node.WritePLY(&buf, PLYOptions{
+		Colors: map[string][3]float64{"red": {1, 0, 0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, line := range []string{
+		"element vertex 8\n",
+		"element face 6\n",
+		"property uchar red\n",
+		"property double weight\n",
+		"comment tag 0 red\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("Expected %q in header", line)
+		}
+	}
+	body := strings.SplitN(out, "end_header\n", 2)[1]
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	if len(lines) != 14 {
+		t.Fatalf("Expected 14 lines after the header, got %v", len(lines))
+	}
+	if !strings.HasSuffix(lines[0], " 255 0 0") {
+		t.Errorf("Expected red vertex, got %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[13], " 0 2") {
+		t.Errorf("Expected tag 0 and weight 2 on the last face, got %q", lines[13])
+	}
+}
+
+func TestWritePLYBinary(t *testing.T) {
+	var buf bytes.Buffer
+	err := testCube().WritePLY(&buf, PLYOptions{Binary: true, Triangulate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.SplitN(buf.String(), "end_header\n", 2)
+	if !strings.Contains(parts[0], "format binary_little_endian 1.0\n") {
+		t.Errorf("Expected binary format, got %q", parts[0])
+	}
+	// 8 vertices of 3 floats, 12 triangles of a count, 3 indices and a tag
+	if expected := 8*12 + 12*(1+3*4+4); len(parts[1]) != expected {
+		t.Errorf("Expected %v bytes of data, got %v", expected, len(parts[1]))
+	}
+}
+
+func TestWritePLYReservedMeta(t *testing.T) {
+	node := testCube()
+	node.SetMeta("tag", 1)
+	node.SetMeta("meta_tag", 2)
+	node.SetMeta("vertex indices", 3)
+	var buf bytes.Buffer
+	if err := node.WritePLY(&buf, PLYOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	header := strings.SplitN(buf.String(), "end_header\n", 2)[0]
+	if count := strings.Count(header, " tag\n"); count != 1 {
+		t.Errorf("Expected a single tag property, got %v", count)
+	}
+	for _, line := range []string{
+		"property double meta_tag\n",
+		"property double meta_meta_tag\n",
+		"property double meta_vertex_indices\n",
+	} {
+		if !strings.Contains(header, line) {
+			t.Errorf("Expected %q in header, got %q", line, header)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package toothpaste
+
+import (
+	"math"
+)
+
+// signedArea is positive for counter-clockwise rings
+func signedArea(vertices []*Vertex2D) float64 {
+	var area float64
+	for i, v := range vertices {
+		next := vertices[(i+1)%len(vertices)]
+		area += v.X*next.Y - next.X*v.Y
+	}
+	return area / 2
+}
+
+// pointInRing uses the even-odd rule, points exactly on an edge may
+// land either side
+func pointInRing(x, y float64, vertices []*Vertex2D) bool {
+	inside := false
+	for i, j := 0, len(vertices)-1; i < len(vertices); j, i = i, i+1 {
+		a, b := vertices[i], vertices[j]
+		if (a.Y > y) != (b.Y > y) && x < (b.X-a.X)*(y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func reverseVertices(vertices []*Vertex2D) {
+	for i, j := 0, len(vertices)-1; i < j; i, j = i+1, j-1 {
+		vertices[i], vertices[j] = vertices[j], vertices[i]
+	}
+}
+
+// cleanRing removes consecutive duplicate vertices, including a last
+// vertex that repeats the first
+func cleanRing(vertices []*Vertex2D, tolerance float64) []*Vertex2D {
+	var res []*Vertex2D
+	for _, v := range vertices {
+		if len(res) > 0 && res[len(res)-1].Distance(v) <= tolerance {
+			continue
+		}
+		res = append(res, v)
+	}
+	for len(res) > 1 && res[len(res)-1].Distance(res[0]) <= tolerance {
+		res = res[:len(res)-1]
+	}
+	return res
+}
+
+// shapesFromRings sorts closed rings into outer shapes and holes by how
+// deeply each ring is nested inside the others (even-odd). Each hole is
+// given to the smallest ring that contains it, and orientations are
+// fixed so outers run counter-clockwise and holes clockwise
+func shapesFromRings(rings []*Face2D) []*Shape2D {
+	var valid []*Face2D
+	var areas []float64
+	for _, ring := range rings {
+		if len(ring.Vertices) < 3 {
+			continue
+		}
+		area := signedArea(ring.Vertices)
+		if area == 0 {
+			continue
+		}
+		valid = append(valid, ring)
+		areas = append(areas, math.Abs(area))
+	}
+
+	depth := make([]int, len(valid))
+	parent := make([]int, len(valid))
+	for i, ring := range valid {
+		parent[i] = -1
+		sample := ringSample(ring.Vertices)
+		for j, other := range valid {
+			if i == j || areas[j] < areas[i] {
+				continue
+			}
+			if pointInRing(sample.X, sample.Y, other.Vertices) {
+				depth[i]++
+				if parent[i] == -1 || areas[j] < areas[parent[i]] {
+					parent[i] = j
+				}
+			}
+		}
+	}
+
+	var shapes []*Shape2D
+	shapeOf := map[int]*Shape2D{}
+	for i, ring := range valid {
+		if depth[i]%2 == 0 {
+			if signedArea(ring.Vertices) < 0 {
+				reverseVertices(ring.Vertices)
+			}
+			shape := NewShape2D(ring)
+			shapeOf[i] = shape
+			shapes = append(shapes, shape)
+		}
+	}
+	for i, ring := range valid {
+		if depth[i]%2 == 1 {
+			if signedArea(ring.Vertices) > 0 {
+				reverseVertices(ring.Vertices)
+			}
+			if shape, ok := shapeOf[parent[i]]; ok {
+				shape.Holes = append(shape.Holes, ring)
+			}
+		}
+	}
+	return shapes
+}
+
+// ringSample returns a point just inside the ring, next to the middle
+// of its longest edge, which is safer to test than a vertex since
+// vertices are often shared with touching rings
+func ringSample(vertices []*Vertex2D) *Vertex2D {
+	best, length := 0, -1.0
+	for i, v := range vertices {
+		if d := v.Distance(vertices[(i+1)%len(vertices)]); d > length {
+			best, length = i, d
+		}
+	}
+	a, b := vertices[best], vertices[(best+1)%len(vertices)]
+	// step towards the inside, which is left for counter-clockwise rings
+	side := 1.0
+	if signedArea(vertices) < 0 {
+		side = -1
+	}
+	step := length * 1e-6 * side
+	return NewVertex2D(
+		(a.X+b.X)/2-(b.Y-a.Y)/length*step,
+		(a.Y+b.Y)/2+(b.X-a.X)/length*step,
+	)
+}
+
+// windingNumber counts how many times the ring goes around the point,
+// positive for counter-clockwise
+func windingNumber(x, y float64, vertices []*Vertex2D) int {
+	winding := 0
+	for i, a := range vertices {
+		b := vertices[(i+1)%len(vertices)]
+		side := (b.X-a.X)*(y-a.Y) - (x-a.X)*(b.Y-a.Y)
+		if a.Y <= y {
+			if b.Y > y && side > 0 {
+				winding++
+			}
+		} else if b.Y <= y && side < 0 {
+			winding--
+		}
+	}
+	return winding
+}
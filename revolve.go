@@ -0,0 +1,137 @@
+package toothpaste
+
+import (
+	"fmt"
+	"math"
+)
+
+// Revolve spins the face around an axis like a lathe, with X in the
+// face as the distance from the axis and Y as the distance along it.
+// The band swept by each edge is made of one Node per segment, tagged
+// tags[i] for edge i, or "ring<i>" if there aren't enough tags. Vertices
+// on the axis are shared by every segment. A partial turn can be closed
+// off with caps, tagged "start" and "end". A full turn needs at least 3
+// segments to have any volume, so fewer are raised to 3
+func (f *Face2D) Revolve(axis Axis, degrees float64, segments int, caps bool, tags ...string) *Node {
+	profile := append([]*Vertex2D{}, cleanRing(f.Vertices, 0)...)
+	if len(profile) < 2 || segments < 1 || degrees == 0 {
+		return nil
+	}
+	// outside is on the right of each edge when counter-clockwise
+	side := 1.0
+	if signedArea(profile) < 0 {
+		side = -1
+	}
+	full := math.Abs(degrees) >= 360
+	if full {
+		degrees = math.Copysign(360, degrees)
+		if segments < 3 {
+			segments = 3
+		}
+	}
+	tolerance := ringTolerance(profile)
+
+	place := func(v *Vertex2D, deg float64) *Vertex3D {
+		var res *Vertex3D
+		switch axis {
+		case XAxis:
+			res = NewLabelledVertex3DWithUV(v.Y, v.X, 0, v.U, v.V, v.Label)
+		case ZAxis:
+			res = NewLabelledVertex3DWithUV(v.X, 0, v.Y, v.U, v.V, v.Label)
+		default:
+			res = NewLabelledVertex3DWithUV(v.X, v.Y, 0, v.U, v.V, v.Label)
+		}
+		res.Rotate(deg, axis)
+		return res
+	}
+	// the vertices of each ring, with one shared vertex on the axis
+	rings := make([][]*Vertex3D, len(profile))
+	for k, v := range profile {
+		onAxis := math.Abs(v.X) <= tolerance
+		for j := 0; j <= segments; j++ {
+			switch {
+			case j == segments && full:
+				rings[k] = append(rings[k], rings[k][0])
+			case j > 0 && onAxis:
+				rings[k] = append(rings[k], rings[k][0])
+			default:
+				rings[k] = append(rings[k], place(v, degrees*float64(j)/float64(segments)))
+			}
+		}
+	}
+
+	var nodes []*Node
+	for i, a := range profile {
+		b := profile[(i+1)%len(profile)]
+		if math.Abs(a.X) <= tolerance && math.Abs(b.X) <= tolerance {
+			// the edge lies on the axis, so sweeps nothing
+			continue
+		}
+		tag := getTag(i, tags)
+		if tag == "" {
+			tag = fmt.Sprintf("ring%d", i)
+		}
+		// which way out of the profile this edge faces, in 3D
+		length := a.Distance(b)
+		mid := NewVertex2D((a.X+b.X)/2, (a.Y+b.Y)/2)
+		out := NewVertex2D(mid.X+side*(b.Y-a.Y)/length, mid.Y-side*(b.X-a.X)/length)
+		for j := 0; j < segments; j++ {
+			ra, rb := rings[i], rings[(i+1)%len(profile)]
+			var vertices []*Vertex3D
+			for _, v := range []*Vertex3D{ra[j], rb[j], rb[j+1], ra[j+1]} {
+				if len(vertices) == 0 || vertices[len(vertices)-1] != v {
+					vertices = append(vertices, v)
+				}
+			}
+			if vertices[len(vertices)-1] == vertices[0] {
+				vertices = vertices[:len(vertices)-1]
+			}
+			face := &Face3D{Vertices: vertices}
+			deg := degrees * (float64(j) + 0.5) / float64(segments)
+			outward := place(out, deg).Subtract(place(mid, deg))
+			if face.Normal().Dot(outward) < 0 {
+				face.Flip()
+			}
+			nodes = append(nodes, NewTaggedNode(tag, face))
+		}
+	}
+
+	if caps && !full {
+		for _, end := range []int{0, segments} {
+			face := &Face3D{}
+			for k := range profile {
+				v := rings[k][end]
+				if len(face.Vertices) == 0 || face.Vertices[len(face.Vertices)-1] != v {
+					face.Vertices = append(face.Vertices, v)
+				}
+			}
+			// caps face back the way the turn came from at the start, and
+			// on the way it was going at the end
+			step := degrees / float64(segments) / 2
+			if end == segments {
+				step = -step
+			}
+			at := func(deg float64) *Vertex3D {
+				f := &Face3D{}
+				for _, v := range profile {
+					f.Vertices = append(f.Vertices, place(v, deg))
+				}
+				return f.Centroid()
+			}
+			deg := degrees * float64(end) / float64(segments)
+			tag := "start"
+			if end == segments {
+				tag = "end"
+			}
+			if face.Normal().Dot(at(deg+step).Subtract(at(deg))) > 0 {
+				face.Flip()
+			}
+			nodes = append(nodes, NewTaggedNode(tag, face))
+		}
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+	NewLinkedNodes(nodes...)
+	return nodes[0]
+}
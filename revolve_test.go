@@ -0,0 +1,86 @@
+package toothpaste
+
+import (
+	"math"
+	"testing"
+)
+
+// volume adds up the signed volume inside the faces of a closed chain,
+// which is only positive if they all face outwards
+func volume(n *Node) float64 {
+	total := 0.0
+	for _, node := range n.Nodes() {
+		v := node.Outer.Vertices
+		for i := 1; i+1 < len(v); i++ {
+			total += v[0].Dot(v[i].Cross(v[i+1])) / 6
+		}
+	}
+	return total
+}
+
+func TestRevolve(t *testing.T) {
+	// a square ring like a washer
+	n := NewFace2D(1, 0, 2, 0, 2, 1, 1, 1).Revolve(YAxis, 360, 8, true)
+	if count := len(n.Nodes()); count != 32 {
+		t.Fatalf("Expected 32 nodes, got %v", count)
+	}
+	if count := len(n.GetAll("ring1")); count != 8 {
+		t.Errorf("Expected 8 nodes in the ring, got %v", count)
+	}
+	// octagons with corners at each radius
+	expected := 2 * math.Sqrt2 * (4 - 1)
+	if v := volume(n); math.Abs(v-expected) > 1e-9 {
+		t.Errorf("Expected volume %v, got %v", expected, v)
+	}
+	for _, v := range n.Outer.Vertices {
+		if math.Abs(v.Y) > 1e-9 {
+			t.Errorf("Expected the first ring to lie flat, got %v", v)
+		}
+	}
+
+	// too few segments for a full turn are raised to 3, triangles
+	for _, segments := range []int{1, 2} {
+		n := NewFace2D(1, 0, 2, 0, 2, 1, 1, 1).Revolve(YAxis, 360, segments, true)
+		if count := len(n.GetAll("ring1")); count != 3 {
+			t.Errorf("Expected 3 segments, got %v", count)
+		}
+		if v, expected := volume(n), 9*math.Sqrt(3)/4; math.Abs(v-expected) > 1e-9 {
+			t.Errorf("Expected volume %v, got %v", expected, v)
+		}
+	}
+}
+
+func TestRevolvePartial(t *testing.T) {
+	// a quarter of a cone, on its side around the x axis
+	n := NewFace2D(0, 0, 0, 1, 1, 0).Revolve(XAxis, 90, 16, true, "base", "side")
+	if count := len(n.Nodes()); count != 2*16+2 {
+		t.Fatalf("Expected 34 nodes, got %v", count)
+	}
+	if count := len(n.GetAll("start", "end")); count != 2 {
+		t.Errorf("Expected 2 caps, got %v", count)
+	}
+	if count := len(n.GetAll("side")); count != 16 {
+		t.Errorf("Expected 16 sides, got %v", count)
+	}
+	base := 16 * 0.5 * math.Sin(math.Pi/2/16)
+	if v := volume(n); math.Abs(v-base/3) > 1e-9 {
+		t.Errorf("Expected volume %v, got %v", base/3, v)
+	}
+	// the tip is welded into one vertex
+	tips := map[*Vertex3D]bool{}
+	for _, node := range n.GetAll("side") {
+		for _, v := range node.Outer.Vertices {
+			if v.Y == 0 && v.Z == 0 {
+				tips[v] = true
+			}
+		}
+	}
+	if len(tips) != 1 {
+		t.Errorf("Expected 1 tip vertex, got %v", len(tips))
+	}
+
+	// without caps it's open
+	if count := len(NewFace2D(0, 0, 0, 1, 1, 0).Revolve(XAxis, 90, 16, false).Nodes()); count != 32 {
+		t.Errorf("Expected 32 nodes, got %v", count)
+	}
+}
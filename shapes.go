@@ -1,6 +1,7 @@
 package toothpaste
 
 import (
+	"fmt"
 	"math"
 )
 
@@ -29,3 +30,338 @@ func Triangle(w, d float64) *Face2D {
 		w, 0,
 	)
 }
+
+// labelled sets the labels of the face's vertices in order
+func labelled(f *Face2D, labels ...string) *Face2D {
+	for i, label := range labels {
+		f.Vertices[i].Label = label
+	}
+	return f
+}
+
+// ellipsePoint is a point on the ellipse that fills a w by h box
+func ellipsePoint(w, h, angle float64) *Vertex2D {
+	return NewVertex2D(w/2+w/2*math.Cos(angle), h/2+h/2*math.Sin(angle))
+}
+
+// RegularPolygon fits a polygon with the given number of sides in a w by
+// h box, with a flat bottom edge. It is stretched to fill the box, so a
+// square is w by h rather than inscribed in a circle. Vertices are
+// labelled corner0 onwards
+func RegularPolygon(w, h float64, sides int) *Face2D {
+	f := &Face2D{}
+	for i := 0; i < sides; i++ {
+		angle := -math.Pi/2 - math.Pi/float64(sides) + 2*math.Pi*float64(i)/float64(sides)
+		v := NewVertex2D(math.Cos(angle), math.Sin(angle))
+		v.Label = fmt.Sprintf("corner%d", i)
+		f.Vertices = append(f.Vertices, v)
+	}
+	if sides < 3 {
+		return f
+	}
+	minX, minY, maxX, maxY := f.Bounds()
+	for _, v := range f.Vertices {
+		v.X = (v.X - minX) / (maxX - minX) * w
+		v.Y = (v.Y - minY) / (maxY - minY) * h
+	}
+	return f
+}
+
+// Star has its tips on the edge of the w by h box, the first pointing
+// straight up, and its valleys inner times as far from the center.
+// Vertices are labelled tip0, valley0, tip1 and so on
+func Star(w, h float64, points int, inner float64) *Face2D {
+	f := &Face2D{}
+	for i := 0; i < points*2; i++ {
+		angle := math.Pi/2 + math.Pi*float64(i)/float64(points)
+		if i%2 == 0 {
+			v := ellipsePoint(w, h, angle)
+			v.Label = fmt.Sprintf("tip%d", i/2)
+			f.Vertices = append(f.Vertices, v)
+		} else {
+			v := ellipsePoint(w*inner, h*inner, angle)
+			v.Translate(w*(1-inner)/2, h*(1-inner)/2)
+			v.Label = fmt.Sprintf("valley%d", i/2)
+			f.Vertices = append(f.Vertices, v)
+		}
+	}
+	return f
+}
+
+// Ellipse is the same shape as Circle, with the vertices at the
+// extremes labelled right, top, left and bottom
+func Ellipse(w, h float64, resolution int) *Face2D {
+	f := Circle(w, h, resolution)
+	names := []string{"right", "top", "left", "bottom"}
+	for i, v := range f.Vertices {
+		if (i*4)%resolution == 0 {
+			v.Label = names[i*4/resolution]
+		}
+	}
+	return f
+}
+
+// EllipseSector is a slice of the ellipse filling a w by h box, from
+// start to end degrees counter-clockwise. The vertices at the center
+// and either end are labelled center, start and end
+func EllipseSector(w, h, start, end float64, resolution int) *Face2D {
+	f := &Face2D{Vertices: []*Vertex2D{NewVertex2D(w/2, h/2)}}
+	f.Vertices[0].Label = "center"
+	f.Vertices = append(f.Vertices, ellipseArcPoints(w, h, start, end, resolution)...)
+	f.Vertices[1].Label = "start"
+	f.Vertices[len(f.Vertices)-1].Label = "end"
+	return f
+}
+
+// EllipseArc is a curved strip of the given thickness running inside the
+// edge of the ellipse from start to end degrees. The ends are labelled
+// outer-start, outer-end, inner-end and inner-start
+func EllipseArc(w, h, thickness, start, end float64, resolution int) *Face2D {
+	outer := ellipseArcPoints(w, h, start, end, resolution)
+	inner := ellipseArcPoints(w-2*thickness, h-2*thickness, start, end, resolution)
+	outer[0].Label, outer[len(outer)-1].Label = "outer-start", "outer-end"
+	inner[0].Label, inner[len(inner)-1].Label = "inner-start", "inner-end"
+	f := &Face2D{Vertices: outer}
+	for i := len(inner) - 1; i >= 0; i-- {
+		inner[i].Translate(thickness, thickness)
+		f.Vertices = append(f.Vertices, inner[i])
+	}
+	return f
+}
+
+func ellipseArcPoints(w, h, start, end float64, resolution int) []*Vertex2D {
+	var points []*Vertex2D
+	for i := 0; i <= resolution; i++ {
+		angle := (start + (end-start)*float64(i)/float64(resolution)) * math.Pi / 180
+		points = append(points, ellipsePoint(w, h, angle))
+	}
+	return points
+}
+
+// Annulus is a ring with the given thickness, as an ellipse with an
+// ellipse shaped hole. Both are labelled like Ellipse, with outer- and
+// inner- in front
+func Annulus(w, h, thickness float64, resolution int) *Shape2D {
+	outer := Ellipse(w, h, resolution)
+	inner := Ellipse(w-2*thickness, h-2*thickness, resolution)
+	inner.Translate(thickness, thickness)
+	reverseVertices(inner.Vertices)
+	for _, v := range outer.Vertices {
+		if v.Label != "" {
+			v.Label = "outer-" + v.Label
+		}
+	}
+	for _, v := range inner.Vertices {
+		if v.Label != "" {
+			v.Label = "inner-" + v.Label
+		}
+	}
+	return NewShape2D(outer, inner)
+}
+
+// RoundedRectangle is a w by d rectangle with each corner rounded by an
+// arc of the given radius, limited to half the shortest side. The points
+// of each arc are labelled with the corner name and their position
+// along it, such as bottom-left0
+func RoundedRectangle(w, d, radius float64, segments int) *Face2D {
+	radius = math.Min(radius, math.Min(w, d)/2)
+	if segments < 1 {
+		segments = 1
+	}
+	corners := []struct {
+		name   string
+		x, y   float64
+		offset float64
+	}{
+		{"bottom-left", radius, radius, math.Pi},
+		{"bottom-right", w - radius, radius, 1.5 * math.Pi},
+		{"top-right", w - radius, d - radius, 0},
+		{"top-left", radius, d - radius, 0.5 * math.Pi},
+	}
+	f := &Face2D{}
+	for _, c := range corners {
+		for i := 0; i <= segments; i++ {
+			angle := c.offset + math.Pi/2*float64(i)/float64(segments)
+			v := NewVertex2D(c.x+radius*math.Cos(angle), c.y+radius*math.Sin(angle))
+			v.Label = fmt.Sprintf("%v%d", c.name, i)
+			f.Vertices = append(f.Vertices, v)
+		}
+	}
+	f.Vertices = cleanRing(f.Vertices, 0)
+	return f
+}
+
+// Capsule is a w by h slot, a rectangle with semicircles on its shorter
+// ends. The tips of the ends are labelled left and right (or bottom and
+// top if it is taller than it is wide)
+func Capsule(w, h float64, segments int) *Face2D {
+	if segments < 2 {
+		segments = 2
+	}
+	r := math.Min(w, h) / 2
+	ends := []struct {
+		x, y, offset float64
+		tip          string
+	}{
+		{w - r, r, -math.Pi / 2, "right"},
+		{r, h - r, math.Pi / 2, "left"},
+	}
+	if h > w {
+		ends = []struct {
+			x, y, offset float64
+			tip          string
+		}{
+			{r, r, math.Pi, "bottom"},
+			{r, h - r, 0, "top"},
+		}
+	}
+	f := &Face2D{}
+	for _, e := range ends {
+		for i := 0; i <= segments; i++ {
+			angle := e.offset + math.Pi*float64(i)/float64(segments)
+			v := NewVertex2D(e.x+r*math.Cos(angle), e.y+r*math.Sin(angle))
+			if i*2 == segments {
+				v.Label = e.tip
+			}
+			f.Vertices = append(f.Vertices, v)
+		}
+	}
+	f.Vertices = cleanRing(f.Vertices, 0)
+	return f
+}
+
+// Trapezoid has a bottom edge of width bottom and a top edge of width top
+// centered over it, h above
+func Trapezoid(bottom, top, h float64) *Face2D {
+	w := math.Max(bottom, top)
+	return labelled(NewFace2D(
+		(w-bottom)/2, 0,
+		(w+bottom)/2, 0,
+		(w+top)/2, h,
+		(w-top)/2, h,
+	), "bottom-left", "bottom-right", "top-right", "top-left")
+}
+
+// LProfile is an angle section with legs w wide and h tall, both of the
+// given thickness
+func LProfile(w, h, thickness float64) *Face2D {
+	return labelled(NewFace2D(
+		0, 0,
+		w, 0,
+		w, thickness,
+		thickness, thickness,
+		thickness, h,
+		0, h,
+	), "bottom-left", "bottom-right", "leg-end-bottom", "inner", "leg-end-top", "top-left")
+}
+
+// TProfile is a tee section with a flange across the top and a web down
+// the middle
+func TProfile(w, h, web, flange float64) *Face2D {
+	left, right := (w-web)/2, (w+web)/2
+	return labelled(NewFace2D(
+		left, 0,
+		right, 0,
+		right, h-flange,
+		w, h-flange,
+		w, h,
+		0, h,
+		0, h-flange,
+		left, h-flange,
+	), "web-bottom-left", "web-bottom-right", "inner-right", "flange-bottom-right",
+		"top-right", "top-left", "flange-bottom-left", "inner-left")
+}
+
+// UProfile is a channel section opening upwards, with a base of the given
+// thickness and walls either side
+func UProfile(w, h, wall, base float64) *Face2D {
+	return labelled(NewFace2D(
+		0, 0,
+		w, 0,
+		w, h,
+		w-wall, h,
+		w-wall, base,
+		wall, base,
+		wall, h,
+		0, h,
+	), "bottom-left", "bottom-right", "top-right", "inner-top-right",
+		"inner-bottom-right", "inner-bottom-left", "inner-top-left", "top-left")
+}
+
+// IProfile is an I beam section with flanges across the top and bottom
+// joined by a web down the middle
+func IProfile(w, h, web, flange float64) *Face2D {
+	left, right := (w-web)/2, (w+web)/2
+	return labelled(NewFace2D(
+		0, 0,
+		w, 0,
+		w, flange,
+		right, flange,
+		right, h-flange,
+		w, h-flange,
+		w, h,
+		0, h,
+		0, h-flange,
+		left, h-flange,
+		left, flange,
+		0, flange,
+	), "bottom-left", "bottom-right", "bottom-flange-right", "inner-bottom-right",
+		"inner-top-right", "top-flange-right", "top-right", "top-left",
+		"top-flange-left", "inner-top-left", "inner-bottom-left", "bottom-flange-left")
+}
+
+// Gear is the outline of a spur gear with involute teeth and a 20 degree
+// pressure angle, sized by its module (pitch diameter over tooth count).
+// It fills a box the size of the tip diameter and each flank is made of
+// resolution segments. The middle of each tooth tip is labelled tooth0
+// onwards
+func Gear(module float64, teeth, resolution int) *Face2D {
+	if resolution < 1 {
+		resolution = 1
+	}
+	pressure := 20 * math.Pi / 180
+	pitch := module * float64(teeth) / 2
+	base := pitch * math.Cos(pressure)
+	tip := pitch + module
+	root := math.Max(pitch-1.25*module, 0)
+
+	// the involute starts at the base circle, so teeth on small gears
+	// go straight down from there to the root
+	involute := func(t float64) float64 {
+		return t - math.Atan(t)
+	}
+	inv := math.Tan(pressure) - pressure
+	halfTooth := math.Pi / (2 * float64(teeth))
+	tMin := math.Sqrt(math.Max(0, math.Pow(math.Max(root, base)/base, 2)-1))
+	tMax := math.Sqrt(math.Pow(tip/base, 2) - 1)
+
+	f := &Face2D{}
+	add := func(radius, angle float64, label string) {
+		v := NewVertex2D(tip+radius*math.Cos(angle), tip+radius*math.Sin(angle))
+		v.Label = label
+		f.Vertices = append(f.Vertices, v)
+	}
+	for k := 0; k < teeth; k++ {
+		center := 2 * math.Pi * float64(k) / float64(teeth)
+		// half the angle the tooth covers at the radius for t
+		half := func(t float64) float64 {
+			return halfTooth + inv - involute(t)
+		}
+		if root < base {
+			add(root, center-half(tMin), "")
+		}
+		for i := 0; i <= resolution; i++ {
+			t := tMin + (tMax-tMin)*float64(i)/float64(resolution)
+			add(base*math.Sqrt(1+t*t), center-half(t), "")
+		}
+		add(tip, center, fmt.Sprintf("tooth%d", k))
+		for i := resolution; i >= 0; i-- {
+			t := tMin + (tMax-tMin)*float64(i)/float64(resolution)
+			add(base*math.Sqrt(1+t*t), center+half(t), "")
+		}
+		if root < base {
+			add(root, center+half(tMin), "")
+		}
+	}
+	return f
+}
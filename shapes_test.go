@@ -0,0 +1,72 @@
+package toothpaste
+
+import (
+	"math"
+	"testing"
+)
+
+func TestShapesValid(t *testing.T) {
+	for name, f := range map[string]*Face2D{
+		"polygon":   RegularPolygon(2, 2, 6),
+		"star":      Star(2, 2, 5, 0.4),
+		"ellipse":   Ellipse(4, 2, 32),
+		"sector":    EllipseSector(2, 2, 0, 270, 16),
+		"arc":       EllipseArc(2, 2, 0.2, 0, 180, 16),
+		"rounded":   RoundedRectangle(4, 2, 0.5, 4),
+		"capsule":   Capsule(4, 1, 8),
+		"tall":      Capsule(1, 4, 8),
+		"trapezoid": Trapezoid(4, 2, 1),
+		"l":         LProfile(2, 3, 0.2),
+		"t":         TProfile(2, 3, 0.2, 0.3),
+		"u":         UProfile(2, 3, 0.2, 0.3),
+		"i":         IProfile(2, 3, 0.2, 0.3),
+		"gear":      Gear(1, 20, 4),
+		"pinion":    Gear(1, 8, 4),
+	} {
+		if err := f.Validate(); err != nil {
+			t.Errorf("Expected %v to be valid, got %v", name, err)
+		}
+		if f.IsClockwise() {
+			t.Errorf("Expected %v to be counter-clockwise", name)
+		}
+	}
+
+	annulus := Annulus(2, 2, 0.5, 32)
+	if err := annulus.Validate(); err != nil {
+		t.Errorf("Expected the annulus to be valid, got %v", err)
+	}
+	if annulus.Holes[0].Find("inner-top") == nil || annulus.Outer.Find("outer-left") == nil {
+		t.Errorf("Expected labelled extremes")
+	}
+}
+
+func TestShapesSize(t *testing.T) {
+	if area := IProfile(2, 3, 0.2, 0.5).Area(); math.Abs(area-2.4) > 1e-9 {
+		t.Errorf("Expected I profile area 2.4, got %v", area)
+	}
+	if area := RoundedRectangle(4, 2, 0.5, 64).Area(); math.Abs(area-(8-(1-math.Pi/4))) > 1e-3 {
+		t.Errorf("Expected rounded rectangle area %v, got %v", 8-(1-math.Pi/4), area)
+	}
+	if area := Capsule(4, 2, 64).Area(); math.Abs(area-(4+math.Pi)) > 1e-2 {
+		t.Errorf("Expected capsule area %v, got %v", 4+math.Pi, area)
+	}
+
+	gear := Gear(2, 12, 4)
+	minX, _, maxX, _ := gear.Bounds()
+	if math.Abs(maxX-minX-28) > 1e-2 {
+		t.Errorf("Expected the gear to be the tip diameter 28 across, got %v", maxX-minX)
+	}
+	if gear.Find("tooth11") == nil {
+		t.Errorf("Expected the teeth to be labelled")
+	}
+	square := RegularPolygon(1, 1, 4)
+	if minX, minY, maxX, maxY := square.Bounds(); minX != 0 || minY != 0 || math.Abs(maxX-1) > 1e-9 || math.Abs(maxY-1) > 1e-9 {
+		t.Errorf("Expected the polygon to fill the box, got %v, %v to %v, %v", minX, minY, maxX, maxY)
+	}
+	if area := RegularPolygon(2, 1, 6).Area(); math.Abs(area-1.5) > 1e-9 {
+		t.Errorf("Expected hexagon area 1.5, got %v", area)
+	}
+	if v := Star(2, 2, 5, 0.5).Find("tip0"); math.Abs(v.X-1) > 1e-9 || math.Abs(v.Y-2) > 1e-9 {
+		t.Errorf("Expected the first tip at the top, got %v", v)
+	}
+}
@@ -0,0 +1,157 @@
+package toothpaste
+
+import (
+	"math"
+	"sort"
+)
+
+// ConvexHull returns the smallest convex face around all the vertices,
+// counter-clockwise, using copies of the vertices on the hull
+func (f *Face2D) ConvexHull() *Face2D {
+	points := make([]*Vertex2D, len(f.Vertices))
+	copy(points, f.Vertices)
+	sort.SliceStable(points, func(i, j int) bool {
+		if points[i].X != points[j].X {
+			return points[i].X < points[j].X
+		}
+		return points[i].Y < points[j].Y
+	})
+	if len(points) < 3 {
+		return copyVertices(points)
+	}
+	cross := func(o, a, b *Vertex2D) float64 {
+		return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+	}
+	// andrew's monotone chain, lower hull then upper hull
+	var hull []*Vertex2D
+	for _, p := range points {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(points) - 2; i >= 0; i-- {
+		p := points[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return copyVertices(hull[:len(hull)-1])
+}
+
+func copyVertices(vertices []*Vertex2D) *Face2D {
+	f := &Face2D{}
+	for _, v := range vertices {
+		f.Vertices = append(f.Vertices, v.Copy())
+	}
+	return f
+}
+
+// Simplify removes vertices with the Ramer-Douglas-Peucker algorithm,
+// so that no removed vertex was further than tolerance from the new
+// outline. The vertices kept are copies
+func (f *Face2D) Simplify(tolerance float64) *Face2D {
+	n := len(f.Vertices)
+	if n <= 3 {
+		return copyVertices(f.Vertices)
+	}
+	// split the ring at the vertex furthest from the first, and simplify
+	// both halves as open lines
+	far, dist := 0, -1.0
+	for i, v := range f.Vertices {
+		if d := v.Distance(f.Vertices[0]); d > dist {
+			far, dist = i, d
+		}
+	}
+	keep := make([]bool, n)
+	keep[0], keep[far] = true, true
+	ring := append(append([]*Vertex2D{}, f.Vertices...), f.Vertices[0])
+	simplifyRDP(ring, 0, far, tolerance, keep)
+	simplifyRDP(ring, far, n, tolerance, keep)
+
+	var res []*Vertex2D
+	for i, v := range f.Vertices {
+		if keep[i] {
+			res = append(res, v)
+		}
+	}
+	return copyVertices(res)
+}
+
+func simplifyRDP(vertices []*Vertex2D, first, last int, tolerance float64, keep []bool) {
+	if last-first < 2 {
+		return
+	}
+	index, dist := -1, tolerance
+	for i := first + 1; i < last; i++ {
+		if d := segmentDistance(vertices[i], vertices[first], vertices[last]); d > dist {
+			index, dist = i, d
+		}
+	}
+	if index == -1 {
+		return
+	}
+	keep[index] = true
+	simplifyRDP(vertices, first, index, tolerance, keep)
+	simplifyRDP(vertices, index, last, tolerance, keep)
+}
+
+// SimplifyVisvalingam repeatedly removes the vertex that makes the
+// smallest triangle with its neighbours, until every triangle left is
+// at least area in size. It never goes below three vertices
+func (f *Face2D) SimplifyVisvalingam(area float64) *Face2D {
+	vertices := make([]*Vertex2D, len(f.Vertices))
+	copy(vertices, f.Vertices)
+	triangle := func(i int) float64 {
+		n := len(vertices)
+		a, b, c := vertices[(i+n-1)%n], vertices[i], vertices[(i+1)%n]
+		return math.Abs((b.X-a.X)*(c.Y-a.Y)-(b.Y-a.Y)*(c.X-a.X)) / 2
+	}
+	for len(vertices) > 3 {
+		smallest, index := math.Inf(1), -1
+		for i := range vertices {
+			if a := triangle(i); a < smallest {
+				smallest, index = a, i
+			}
+		}
+		if smallest >= area {
+			break
+		}
+		vertices = append(vertices[:index], vertices[index+1:]...)
+	}
+	return copyVertices(vertices)
+}
+
+// Resample returns a face with n vertices spaced evenly along the
+// perimeter, starting at the first vertex. UVs are interpolated along
+// the edges, and only the first vertex keeps its label
+func (f *Face2D) Resample(n int) *Face2D {
+	res := &Face2D{}
+	perimeter := f.Perimeter()
+	if n <= 0 || len(f.Vertices) == 0 || perimeter == 0 {
+		return res
+	}
+	res.Vertices = append(res.Vertices, f.Vertices[0].Copy())
+	step := perimeter / float64(n)
+	edge, travelled := 0, 0.0
+	for i := 1; i < n; i++ {
+		target := step * float64(i)
+		a, b := f.Vertices[edge], f.Vertices[(edge+1)%len(f.Vertices)]
+		for travelled+a.Distance(b) < target && edge < len(f.Vertices)-1 {
+			travelled += a.Distance(b)
+			edge++
+			a, b = f.Vertices[edge], f.Vertices[(edge+1)%len(f.Vertices)]
+		}
+		t := 0.0
+		if length := a.Distance(b); length > 0 {
+			t = math.Min(1, (target-travelled)/length)
+		}
+		res.Vertices = append(res.Vertices, NewVertex2DWithUV(
+			lerp(a.X, b.X, t), lerp(a.Y, b.Y, t),
+			lerp(a.U, b.U, t), lerp(a.V, b.V, t),
+		))
+	}
+	return res
+}
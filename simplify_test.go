@@ -0,0 +1,60 @@
+package toothpaste
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvexHull(t *testing.T) {
+	f := NewFace2D(0, 0, 2, 0, 1, 1, 2, 2, 0, 2, 0.5, 1)
+	f.Vertices[3].Label = "corner"
+	hull := f.ConvexHull()
+	if len(hull.Vertices) != 4 || hull.Area() != 4 || hull.IsClockwise() {
+		t.Errorf("Expected a counter-clockwise square, got %v", hull.Vertices)
+	}
+	if hull.Find("corner") == nil {
+		t.Errorf("Expected labels to be kept")
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	// a square with a little noise along each side
+	var noisy []float64
+	for i := 0; i < 40; i++ {
+		d := 0.01 * math.Sin(float64(i))
+		s := float64(i%10) / 10
+		switch i / 10 {
+		case 0:
+			noisy = append(noisy, s, d)
+		case 1:
+			noisy = append(noisy, 1+d, s)
+		case 2:
+			noisy = append(noisy, 1-s, 1+d)
+		default:
+			noisy = append(noisy, d, 1-s)
+		}
+	}
+	f := NewFace2D(noisy...)
+	if n := len(f.Simplify(0.05).Vertices); n != 4 {
+		t.Errorf("Expected RDP to leave the 4 corners, got %v", n)
+	}
+	if n := len(f.SimplifyVisvalingam(0.01).Vertices); n != 4 {
+		t.Errorf("Expected Visvalingam to leave the 4 corners, got %v", n)
+	}
+	if n := len(f.Simplify(0.001).Vertices); n <= 4 {
+		t.Errorf("Expected a small tolerance to keep the noise, got %v", n)
+	}
+}
+
+func TestResample(t *testing.T) {
+	f := Square(2, 1).Resample(12)
+	if len(f.Vertices) != 12 {
+		t.Fatalf("Expected 12 vertices, got %v", len(f.Vertices))
+	}
+	for i, v := range f.Vertices {
+		next := f.Vertices[(i+1)%len(f.Vertices)]
+		if d := math.Abs(v.X-next.X) + math.Abs(v.Y-next.Y); math.Abs(d-0.5) > 1e-9 {
+			t.Errorf("Expected steps of 0.5 around the perimeter, got %v", d)
+		}
+	}
+}
@@ -0,0 +1,548 @@
+package toothpaste
+
+import (
+	"math"
+	"sort"
+)
+
+// Skeleton is the straight skeleton of an outline: the lines traced by
+// the corners as every edge moves inwards at the same speed. Each edge
+// of the outline sweeps out one face of the skeleton, which is where a
+// roof plane rising from that edge would be
+type Skeleton struct {
+	Nodes   []*Vertex2D
+	Heights []float64 // how far each node is from the outline
+	Arcs    [][2]int  // pairs of node indices
+	// for each edge of the outline, outer edges first and then holes,
+	// the nodes around the face it sweeps, starting with the edge's own
+	// start and end. Edges of zero length sweep no face and are left nil
+	Faces [][]int
+}
+
+// StraightSkeleton works out the skeleton of the face, which is treated
+// as counter-clockwise whichever way it is wound
+func (f *Face2D) StraightSkeleton() *Skeleton {
+	return NewShape2D(f).StraightSkeleton()
+}
+
+// StraightSkeleton works out the skeleton of the shape, holes included.
+// Edges are numbered around the outer counter-clockwise and then around
+// each hole clockwise
+func (s *Shape2D) StraightSkeleton() *Skeleton {
+	return straightSkeleton(orientedRings(s))
+}
+
+// orientedRings returns copies of the shape's rings with the outer
+// counter-clockwise and holes clockwise, so the inside is always on
+// the left of each edge
+func orientedRings(s *Shape2D) [][]*Vertex2D {
+	var rings [][]*Vertex2D
+	for i, f := range s.Faces() {
+		ring := append([]*Vertex2D{}, f.Vertices...)
+		if (i == 0) != (signedArea(ring) > 0) {
+			reverseVertices(ring)
+		}
+		rings = append(rings, ring)
+	}
+	return rings
+}
+
+// wavefrontVertex is a corner of the shrinking outline. Its position at
+// time t is origin + vel*t, and it sits where its left and right edges
+// meet
+type wavefrontVertex struct {
+	origin      [2]float64
+	vel         [2]float64
+	left, right int
+	prev, next  *wavefrontVertex
+	node        int
+	active      bool
+}
+
+func (v *wavefrontVertex) at(t float64) [2]float64 {
+	return [2]float64{v.origin[0] + v.vel[0]*t, v.origin[1] + v.vel[1]*t}
+}
+
+type skeletonBuilder struct {
+	skeleton  *Skeleton
+	pool      *pointPool
+	start     [][2]float64 // a point on each edge
+	dir       [][2]float64 // unit direction of each edge
+	normal    [][2]float64 // unit inward normal of each edge
+	vertices  []*wavefrontVertex
+	arcEdges  [][2]int // the two edges either side of each arc
+	tolerance float64
+}
+
+// straightSkeleton runs the wavefront forward from one event to the
+// next. An edge event is when an edge shrinks away to nothing and its
+// neighbours meet, and a split event is when a reflex corner runs into
+// an edge and cuts the outline in two (or joins a hole to the outer)
+func straightSkeleton(rings [][]*Vertex2D) *Skeleton {
+	b := &skeletonBuilder{skeleton: &Skeleton{}}
+	var all []*Vertex2D
+	for _, ring := range rings {
+		all = append(all, ring...)
+	}
+	b.tolerance = ringTolerance(all)
+	if b.tolerance == 0 {
+		return b.skeleton
+	}
+	b.pool = newPointPool(b.tolerance)
+
+	// edges too short to have a direction are dropped along with the
+	// vertex they start from, and get no face. edges[k] is the edge of
+	// the rings that edge k of the wavefront came from
+	var edges []int
+	total := 0
+	for _, ring := range rings {
+		var kept []*Vertex2D
+		for i, v := range ring {
+			if v.Distance(ring[(i+1)%len(ring)]) > b.tolerance {
+				kept = append(kept, v)
+				edges = append(edges, total+i)
+			}
+		}
+		total += len(ring)
+		if len(kept) < 3 {
+			edges = edges[:len(edges)-len(kept)]
+			continue
+		}
+		ring = kept
+		first := len(b.start)
+		n := len(ring)
+		for i, v := range ring {
+			next := ring[(i+1)%n]
+			length := v.Distance(next)
+			d := [2]float64{(next.X - v.X) / length, (next.Y - v.Y) / length}
+			b.start = append(b.start, [2]float64{v.X, v.Y})
+			b.dir = append(b.dir, d)
+			b.normal = append(b.normal, [2]float64{-d[1], d[0]})
+		}
+		var loop []*wavefrontVertex
+		for i, v := range ring {
+			left, right := first+(i+n-1)%n, first+i
+			loop = append(loop, b.newVertex([2]float64{v.X, v.Y}, 0, left, right, b.node(v.X, v.Y, 0)))
+		}
+		for i, v := range loop {
+			v.prev, v.next = loop[(i+n-1)%n], loop[(i+1)%n]
+		}
+	}
+
+	t := 0.0
+	for steps := 0; steps < 10*len(b.vertices)+100; steps++ {
+		event, v, u, at := b.nextEvent(t)
+		if event == 0 {
+			break
+		}
+		t = math.Max(t, at)
+		if event == 1 {
+			b.edgeEvent(v, t)
+		} else {
+			b.splitEvent(v, u, t)
+		}
+	}
+	b.faces(len(b.start))
+	if len(edges) != total {
+		faces := make([][]int, total)
+		for k, e := range edges {
+			faces[e] = b.skeleton.Faces[k]
+		}
+		b.skeleton.Faces = faces
+	}
+	return b.skeleton
+}
+
+func (b *skeletonBuilder) node(x, y, height float64) int {
+	id := b.pool.add(x, y)
+	if id == len(b.skeleton.Nodes) {
+		b.skeleton.Nodes = append(b.skeleton.Nodes, NewVertex2D(x, y))
+		b.skeleton.Heights = append(b.skeleton.Heights, height)
+	}
+	return id
+}
+
+func (b *skeletonBuilder) arc(from, to int, left, right int) {
+	if from != to {
+		b.skeleton.Arcs = append(b.skeleton.Arcs, [2]int{from, to})
+		b.arcEdges = append(b.arcEdges, [2]int{left, right})
+	}
+}
+
+// newVertex starts a corner at p at time t, moving so that it stays on
+// both of its edges as they move inwards
+func (b *skeletonBuilder) newVertex(p [2]float64, t float64, left, right, node int) *wavefrontVertex {
+	n1, n2 := b.normal[left], b.normal[right]
+	var vel [2]float64
+	det := n1[0]*n2[1] - n1[1]*n2[0]
+	if math.Abs(det) < 1e-9 {
+		if n1[0]*n2[0]+n1[1]*n2[1] > 0 {
+			vel = n1
+		}
+		// edges facing each other have met, so the corner stays put
+	} else {
+		vel = [2]float64{(n2[1] - n1[1]) / det, (n1[0] - n2[0]) / det}
+	}
+	v := &wavefrontVertex{
+		origin: [2]float64{p[0] - vel[0]*t, p[1] - vel[1]*t},
+		vel:    vel,
+		left:   left,
+		right:  right,
+		node:   node,
+		active: true,
+	}
+	b.vertices = append(b.vertices, v)
+	return v
+}
+
+func (b *skeletonBuilder) reflex(v *wavefrontVertex) bool {
+	d1, d2 := b.dir[v.left], b.dir[v.right]
+	return d1[0]*d2[1]-d1[1]*d2[0] < -1e-9
+}
+
+// nextEvent finds the earliest event after t, returning 1 for an edge
+// event on the edge after v, 2 for v splitting the edge after u, or 0
+// if there are none left
+func (b *skeletonBuilder) nextEvent(t float64) (event int, ev, eu *wavefrontVertex, at float64) {
+	at = math.Inf(1)
+	for _, v := range b.vertices {
+		if !v.active {
+			continue
+		}
+		w := v.next
+		d := b.dir[v.right]
+		rate := (w.vel[0]-v.vel[0])*d[0] + (w.vel[1]-v.vel[1])*d[1]
+		if rate < -1e-12 {
+			when := -((w.origin[0]-v.origin[0])*d[0] + (w.origin[1]-v.origin[1])*d[1]) / rate
+			if when >= t-b.tolerance && when < at {
+				event, ev, at = 1, v, when
+			}
+		}
+		if !b.reflex(v) {
+			continue
+		}
+		for _, u := range b.vertices {
+			if !u.active || u.right == v.left || u.right == v.right {
+				continue
+			}
+			e := u.right
+			n := b.normal[e]
+			denom := v.vel[0]*n[0] + v.vel[1]*n[1] - 1
+			if denom >= -1e-12 {
+				continue
+			}
+			when := ((b.start[e][0]-v.origin[0])*n[0] + (b.start[e][1]-v.origin[1])*n[1]) / denom
+			if when < t-b.tolerance || when >= at {
+				continue
+			}
+			// the point hit has to be within the edge at that time
+			p := v.at(when)
+			pu, pw := u.at(when), u.next.at(when)
+			d := b.dir[e]
+			if (pu[0]-p[0])*d[0]+(pu[1]-p[1])*d[1] > b.tolerance ||
+				(pw[0]-p[0])*d[0]+(pw[1]-p[1])*d[1] < -b.tolerance {
+				continue
+			}
+			event, ev, eu, at = 2, v, u, when
+		}
+	}
+	return event, ev, eu, at
+}
+
+func (b *skeletonBuilder) edgeEvent(v *wavefrontVertex, t float64) {
+	w := v.next
+	pv, pw := v.at(t), w.at(t)
+	node := b.node((pv[0]+pw[0])/2, (pv[1]+pw[1])/2, t)
+	b.arc(v.node, node, v.left, v.right)
+	b.arc(w.node, node, w.left, w.right)
+	v.active, w.active = false, false
+	if w.next == v {
+		return
+	}
+	x := b.newVertex(b.skeleton.Nodes[node].xy(), t, v.left, w.right, node)
+	x.prev, x.next = v.prev, w.next
+	v.prev.next, w.next.prev = x, x
+	b.collapse(x, t)
+}
+
+func (b *skeletonBuilder) splitEvent(v, u *wavefrontVertex, t float64) {
+	p := v.at(t)
+	node := b.node(p[0], p[1], t)
+	b.arc(v.node, node, v.left, v.right)
+	v.active = false
+	w, e := u.next, u.right
+	at := b.skeleton.Nodes[node].xy()
+	v1 := b.newVertex(at, t, v.left, e, node)
+	v2 := b.newVertex(at, t, e, v.right, node)
+	vp, vn := v.prev, v.next
+	vp.next, v1.prev, v1.next, w.prev = v1, vp, w, v1
+	u.next, v2.prev, v2.next, vn.prev = v2, u, vn, v2
+	b.collapse(v1, t)
+	b.collapse(v2, t)
+}
+
+// collapse finishes off a loop that is down to one or two corners, which
+// means the outline around it has closed up
+func (b *skeletonBuilder) collapse(x *wavefrontVertex, t float64) {
+	if !x.active {
+		return
+	}
+	if x.next == x {
+		x.active = false
+		return
+	}
+	if x.next.next != x {
+		return
+	}
+	y := x.next
+	py := y.at(t)
+	node := b.node(py[0], py[1], t)
+	b.arc(y.node, node, y.left, y.right)
+	b.arc(node, x.node, x.left, x.right)
+	x.active, y.active = false, false
+}
+
+func (v *Vertex2D) xy() [2]float64 {
+	return [2]float64{v.X, v.Y}
+}
+
+// faces walks the arcs next to each edge from its end back round to its
+// start
+func (b *skeletonBuilder) faces(edges int) {
+	adjacent := make([]map[int][]int, edges)
+	for i := range adjacent {
+		adjacent[i] = map[int][]int{}
+	}
+	for i, arc := range b.skeleton.Arcs {
+		for _, e := range b.arcEdges[i] {
+			adjacent[e][arc[0]] = append(adjacent[e][arc[0]], arc[1])
+			adjacent[e][arc[1]] = append(adjacent[e][arc[1]], arc[0])
+		}
+	}
+	// the start and end nodes of each edge
+	ends := make([][2]int, edges)
+	for e := range ends {
+		ends[e][0] = b.pool.find(b.start[e][0], b.start[e][1])
+	}
+	for e := range ends {
+		// the next edge of the same ring starts where this one ends,
+		// found through the corner whose left edge is e
+		for _, v := range b.vertices[:edges] {
+			if v.left == e {
+				ends[e][1] = ends[v.right][0]
+			}
+		}
+	}
+
+	for e := 0; e < edges; e++ {
+		from, to := ends[e][1], ends[e][0]
+		// breadth first, so stray arcs can't lead the walk astray
+		prev := map[int]int{from: -1}
+		queue := []int{from}
+		for len(queue) > 0 && queue[0] != to {
+			cur := queue[0]
+			queue = queue[1:]
+			next := adjacent[e][cur]
+			sort.Ints(next)
+			for _, n := range next {
+				if _, ok := prev[n]; !ok && !(cur == from && n == to) {
+					prev[n] = cur
+					queue = append(queue, n)
+				}
+			}
+		}
+		face := []int{to, from}
+		if _, ok := prev[to]; ok {
+			var path []int
+			for n := prev[to]; n != from && n != -1; n = prev[n] {
+				path = append(path, n)
+			}
+			for i := len(path) - 1; i >= 0; i-- {
+				face = append(face, path[i])
+			}
+		}
+		b.skeleton.Faces = append(b.skeleton.Faces, face)
+	}
+}
+
+// Inset shrinks the face by distance, keeping the edges parallel and the
+// corners sharp. Parts narrower than twice the distance disappear, so
+// the result can be several shapes, or none
+func (f *Face2D) Inset(distance float64) []*Shape2D {
+	return NewShape2D(f).Inset(distance)
+}
+
+// Inset shrinks the shape by distance, growing its holes, see
+// Face2D.Inset
+func (s *Shape2D) Inset(distance float64) []*Shape2D {
+	skeleton := s.StraightSkeleton()
+	return skeleton.Inset(distance)
+}
+
+// Inset cuts the skeleton at the given height, giving the outline that
+// the edges have moved to by then
+func (s *Skeleton) Inset(distance float64) []*Shape2D {
+	if distance <= 0 || len(s.Faces) == 0 {
+		return nil
+	}
+	tolerance := ringTolerance(s.Nodes)
+	if tolerance == 0 {
+		return nil
+	}
+	pool := newPointPool(tolerance)
+	var directed [][2]int
+	for _, face := range s.Faces {
+		if len(face) < 2 {
+			continue
+		}
+		a, b := s.Nodes[face[0]], s.Nodes[face[1]]
+		length := a.Distance(b)
+		if length == 0 {
+			continue
+		}
+		dir := [2]float64{(b.X - a.X) / length, (b.Y - a.Y) / length}
+		// where the face boundary crosses the height, worked out from the
+		// arc's nodes in a fixed order so neighbouring faces agree
+		type crossing struct {
+			along float64
+			id    int
+		}
+		var crossings []crossing
+		for i, from := range face {
+			to := face[(i+1)%len(face)]
+			lo, hi := from, to
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			hl, hh := s.Heights[lo], s.Heights[hi]
+			if (hl < distance) == (hh < distance) {
+				continue
+			}
+			k := (distance - hl) / (hh - hl)
+			x := lerp(s.Nodes[lo].X, s.Nodes[hi].X, k)
+			y := lerp(s.Nodes[lo].Y, s.Nodes[hi].Y, k)
+			crossings = append(crossings, crossing{(x-a.X)*dir[0] + (y-a.Y)*dir[1], pool.add(x, y)})
+		}
+		sort.Slice(crossings, func(i, j int) bool {
+			return crossings[i].along < crossings[j].along
+		})
+		// the inside is on the left, so run with the edge
+		for i := 0; i+1 < len(crossings); i += 2 {
+			if crossings[i].id != crossings[i+1].id {
+				directed = append(directed, [2]int{crossings[i].id, crossings[i+1].id})
+			}
+		}
+	}
+	return shapesFromLoops(linkEdges(directed, pool.points), tolerance)
+}
+
+// Roof builds a hip roof on the node, with every plane rising from an
+// edge of the outer face (or a hole) at pitch degrees, away from the
+// normal like Extrude does. The roof planes are added after the node,
+// the plane rising from edge i of the outer tagged tags[i], and hole
+// edges numbered on from there. Edges of zero length, from a repeated
+// vertex, are still counted but get no plane
+func (n *Node) Roof(pitch float64, tags ...string) *Node {
+	return n.GableRoof(pitch, nil, tags...)
+}
+
+// GableRoof builds a roof like Roof, except the edges listed in gables
+// get a vertical gable end instead of a sloping plane, with the planes
+// either side carried out to meet it
+func (n *Node) GableRoof(pitch float64, gables []int, tags ...string) *Node {
+	plane := newFacePlane(n.Outer.Vertices)
+	var rings [][]*Vertex2D
+	var originals []*Vertex3D
+	for i, f := range append([]*Face3D{n.Outer}, n.Inner...) {
+		ring := plane.project(f.Vertices)
+		vertices := append([]*Vertex3D{}, f.Vertices...)
+		if (i == 0) != (signedArea(ring) > 0) {
+			reverseVertices(ring)
+			for l, r := 0, len(vertices)-1; l < r; l, r = l+1, r-1 {
+				vertices[l], vertices[r] = vertices[r], vertices[l]
+			}
+		}
+		rings = append(rings, ring)
+		originals = append(originals, vertices...)
+	}
+	skeleton := straightSkeleton(rings)
+	nodes := make([]*Vertex2D, len(skeleton.Nodes))
+	for i, v := range skeleton.Nodes {
+		nodes[i] = v.Copy()
+	}
+
+	// pull the top of each gable face out onto the gable's edge
+	for _, g := range gables {
+		if g < 0 || g >= len(skeleton.Faces) || skeleton.Faces[g] == nil {
+			continue
+		}
+		face := skeleton.Faces[g]
+		a, b := skeleton.Nodes[face[0]], skeleton.Nodes[face[1]]
+		length := a.Distance(b)
+		for _, id := range face[2:] {
+			p := skeleton.Nodes[id]
+			k := ((p.X-a.X)*(b.X-a.X) + (p.Y-a.Y)*(b.Y-a.Y)) / (length * length)
+			nodes[id] = NewVertex2D(lerp(a.X, b.X, k), lerp(a.Y, b.Y, k))
+		}
+	}
+
+	slope := math.Tan(pitch * math.Pi / 180)
+	lifted := map[int]*Vertex3D{}
+	for i, v := range originals {
+		if id := skeleton.nodeAt(rings, i); id != -1 && lifted[id] == nil {
+			lifted[id] = v
+		}
+	}
+	lift := func(id int) *Vertex3D {
+		if v, ok := lifted[id]; ok {
+			return v
+		}
+		p, h := nodes[id], skeleton.Heights[id]*slope
+		v := NewVertex3D(
+			plane.origin.X+plane.u.X*p.X+plane.v.X*p.Y-plane.normal.X*h,
+			plane.origin.Y+plane.u.Y*p.X+plane.v.Y*p.Y-plane.normal.Y*h,
+			plane.origin.Z+plane.u.Z*p.X+plane.v.Z*p.Y-plane.normal.Z*h,
+		)
+		lifted[id] = v
+		return v
+	}
+
+	next := n.Next
+	cur := n
+	for i, face := range skeleton.Faces {
+		if face == nil {
+			continue
+		}
+		// reversed so the plane faces out of the roof, like ExtrudePoint
+		f := &Face3D{}
+		for j := len(face) - 1; j >= 0; j-- {
+			f.Vertices = append(f.Vertices, lift(face[j]))
+		}
+		node := NewTaggedNode(getTag(i, tags), f)
+		cur.Next, node.Prev = node, cur
+		cur = node
+	}
+	cur.Next = next
+	if next != nil {
+		next.Prev = cur
+	}
+	return n
+}
+
+// nodeAt returns the node for vertex i of the rings, counting through
+// the rings in order
+func (s *Skeleton) nodeAt(rings [][]*Vertex2D, i int) int {
+	for _, ring := range rings {
+		if i < len(ring) {
+			v := ring[i]
+			for id, node := range s.Nodes {
+				if s.Heights[id] == 0 && node.X == v.X && node.Y == v.Y {
+					return id
+				}
+			}
+			return -1
+		}
+		i -= len(ring)
+	}
+	return -1
+}
@@ -0,0 +1,158 @@
+package toothpaste
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStraightSkeletonRectangle(t *testing.T) {
+	skeleton := Square(4, 2).StraightSkeleton()
+	if len(skeleton.Faces) != 4 {
+		t.Fatalf("Expected 4 faces, got %v", len(skeleton.Faces))
+	}
+	// four corners and the two ends of the ridge
+	if len(skeleton.Nodes) != 6 {
+		t.Errorf("Expected 6 nodes, got %v", len(skeleton.Nodes))
+	}
+	highest := 0.0
+	for _, h := range skeleton.Heights {
+		highest = math.Max(highest, h)
+	}
+	if math.Abs(highest-1) > 1e-9 {
+		t.Errorf("Expected the ridge at height 1, got %v", highest)
+	}
+	// long sides are trapezoids, short sides triangles
+	sizes := []int{4, 3, 4, 3}
+	for i, face := range skeleton.Faces {
+		if len(face) != sizes[i] {
+			t.Errorf("Expected face %v to have %v nodes, got %v", i, sizes[i], face)
+		}
+	}
+}
+
+func TestStraightSkeletonHole(t *testing.T) {
+	s := NewShape2D(Square(4, 4), NewFace2D(1, 1, 3, 1, 3, 3, 1, 3))
+	skeleton := s.StraightSkeleton()
+	if len(skeleton.Faces) != 8 {
+		t.Fatalf("Expected 8 faces, got %v", len(skeleton.Faces))
+	}
+	for _, h := range skeleton.Heights {
+		if h > 0.5+1e-9 {
+			t.Errorf("Expected nothing higher than 0.5, got %v", h)
+		}
+	}
+	for i, face := range skeleton.Faces {
+		if len(face) != 4 {
+			t.Errorf("Expected face %v to be a trapezoid, got %v", i, face)
+		}
+	}
+}
+
+func TestStraightSkeletonDuplicate(t *testing.T) {
+	skeleton := NewFace2D(0, 0, 4, 0, 4, 0, 4, 2, 0, 2).StraightSkeleton()
+	if len(skeleton.Faces) != 5 || skeleton.Faces[1] != nil {
+		t.Fatalf("Expected the zero length edge to have no face, got %v", skeleton.Faces)
+	}
+	for _, v := range skeleton.Nodes {
+		if math.IsNaN(v.X) || math.IsNaN(v.Y) {
+			t.Fatalf("Expected no NaN nodes, got %v", skeleton.Nodes)
+		}
+	}
+	if len(skeleton.Nodes) != 6 {
+		t.Errorf("Expected 6 nodes, got %v", len(skeleton.Nodes))
+	}
+
+	node := NewNode(NewFace2D(0, 0, 4, 0, 4, 0, 4, 2, 0, 2).To3D())
+	node.Roof(45, "front", "none", "side", "back", "side")
+	if count := len(node.Nodes()); count != 5 {
+		t.Fatalf("Expected 4 roof planes, got %v", count-1)
+	}
+	if node.Next.Tag != "front" || node.Next.Next.Tag != "side" {
+		t.Errorf("Expected the planes to keep their edge's tag, got %v and %v", node.Next.Tag, node.Next.Next.Tag)
+	}
+	for _, n := range node.Nodes()[1:] {
+		for _, v := range n.Outer.Vertices {
+			if math.IsNaN(v.Y) || v.Y > 1+1e-9 {
+				t.Errorf("Expected the roof to be no higher than 1, got %v", v)
+			}
+		}
+	}
+}
+
+func TestInset(t *testing.T) {
+	l := NewFace2D(0, 0, 2, 0, 2, 1, 1, 1, 1, 2, 0, 2)
+	shapes := l.Inset(0.25)
+	if len(shapes) != 1 {
+		t.Fatalf("Expected 1 shape, got %v", len(shapes))
+	}
+	if area := shapesArea(shapes); math.Abs(area-1.25) > 1e-9 {
+		t.Errorf("Expected area 1.25, got %v", area)
+	}
+
+	// a dumbbell splits in two once the bar is gone
+	dumbbell := NewFace2D(0, 0, 2, 0, 2, 0.9, 4, 0.9, 4, 0, 6, 0, 6, 2, 4, 2, 4, 1.1, 2, 1.1, 2, 2, 0, 2)
+	if shapes := dumbbell.Inset(0.5); len(shapes) != 2 {
+		t.Errorf("Expected 2 shapes, got %v", len(shapes))
+	} else if area := shapesArea(shapes); math.Abs(area-2) > 1e-9 {
+		t.Errorf("Expected area 2, got %v", area)
+	}
+
+	// the hole grows as the outside shrinks
+	s := NewShape2D(Square(4, 4), NewFace2D(1, 1, 3, 1, 3, 3, 1, 3))
+	shapes = s.Inset(0.25)
+	if len(shapes) != 1 || len(shapes[0].Holes) != 1 {
+		t.Fatalf("Expected 1 shape with a hole, got %v", shapes)
+	}
+	if area := shapesArea(shapes); math.Abs(area-(3.5*3.5-2.5*2.5)) > 1e-9 {
+		t.Errorf("Expected area 6, got %v", area)
+	}
+
+	if shapes := Square(2, 2).Inset(1.5); len(shapes) != 0 {
+		t.Errorf("Expected nothing left, got %v", shapes)
+	}
+}
+
+func TestRoof(t *testing.T) {
+	node := NewNode(Square(4, 2).To3D())
+	node.Roof(45, "front", "side", "back", "side")
+	count, highest := 0, 0.0
+	for n := node.Next; n != nil; n = n.Next {
+		count++
+		for _, v := range n.Outer.Vertices {
+			highest = math.Max(highest, v.Y)
+		}
+	}
+	if count != 4 {
+		t.Fatalf("Expected 4 roof planes, got %v", count)
+	}
+	if math.Abs(highest-1) > 1e-9 {
+		t.Errorf("Expected the ridge at 1, got %v", highest)
+	}
+	if node.Next.Tag != "front" || node.Next.Next.Tag != "side" {
+		t.Errorf("Expected planes tagged by edge, got %v and %v", node.Next.Tag, node.Next.Next.Tag)
+	}
+	// eaves share the vertices of the face they were built on
+	if node.Next.Outer.Vertices[len(node.Next.Outer.Vertices)-1] != node.Outer.Vertices[0] {
+		t.Errorf("Expected the eave to reuse the original vertex")
+	}
+	// the roof planes face up and out
+	if normal := node.Next.Outer.Normal(); normal.Y <= 0 || normal.Z >= 0 {
+		t.Errorf("Expected the front plane to face up and forwards, got %v", normal)
+	}
+
+	gable := NewNode(Square(4, 2).To3D())
+	gable.GableRoof(45, []int{1, 3})
+	// the gable ends are vertical triangles
+	for _, i := range []int{1, 3} {
+		n := gable
+		for j := 0; j <= i; j++ {
+			n = n.Next
+		}
+		x := n.Outer.Vertices[0].X
+		for _, v := range n.Outer.Vertices {
+			if math.Abs(v.X-x) > 1e-9 {
+				t.Errorf("Expected gable %v to be vertical, got %v", i, n.Outer.Vertices)
+			}
+		}
+	}
+}
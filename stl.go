@@ -0,0 +1,469 @@
+package toothpaste
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type STLFormat int
+
+const (
+	STLBinary STLFormat = iota
+	STLASCII
+)
+
+// stlTriangle is a single facet with its normal, ready to be written
+type stlTriangle struct {
+	Normal   [3]float64
+	Vertices [3][3]float64
+}
+
+// stlTriangles triangulates every node in the chain (holes included)
+func (n *Node) stlTriangles() []stlTriangle {
+	nodes := n.Nodes()
+	var triangles []stlTriangle
+	for i, face := range nodes.orientedTriangles() {
+		normal := nodes[i].Outer.Normal()
+		for _, t := range face {
+			tri := stlTriangle{Normal: [3]float64{normal.X, normal.Y, normal.Z}}
+			for j, v := range t {
+				tri.Vertices[j] = [3]float64{v.X, v.Y, v.Z}
+			}
+			triangles = append(triangles, tri)
+		}
+	}
+	return triangles
+}
+
+// WriteSTL writes the node chain to w as an STL mesh
+func (n *Node) WriteSTL(w io.Writer, format STLFormat) error {
+	triangles := n.stlTriangles()
+	bw := bufio.NewWriter(w)
+	var err error
+	switch format {
+	case STLASCII:
+		err = writeSTLASCII(bw, triangles)
+	case STLBinary:
+		err = writeSTLBinary(bw, triangles)
+	default:
+		err = fmt.Errorf("unknown STL format %d", format)
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// GenerateSTL writes the node chain to an STL file (binary by default)
+func (n *Node) GenerateSTL(filename string, format ...STLFormat) error {
+	_format := STLBinary
+	if len(format) > 0 {
+		_format = format[0]
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := n.WriteSTL(f, _format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeSTLASCII(w io.Writer, triangles []stlTriangle) error {
+	ew := newErrWriter(w)
+	ew.printf("solid toothpaste\n")
+	for _, tri := range triangles {
+		ew.printf("  facet normal %e %e %e\n", tri.Normal[0], tri.Normal[1], tri.Normal[2])
+		ew.printf("    outer loop\n")
+		for _, v := range tri.Vertices {
+			ew.printf("      vertex %e %e %e\n", v[0], v[1], v[2])
+		}
+		ew.printf("    endloop\n")
+		ew.printf("  endfacet\n")
+	}
+	ew.printf("endsolid toothpaste\n")
+	return ew.flush()
+}
+
+func writeSTLBinary(w io.Writer, triangles []stlTriangle) error {
+	header := make([]byte, 80)
+	copy(header, "toothpaste")
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(triangles))); err != nil {
+		return err
+	}
+	buf := make([]byte, 50)
+	for _, tri := range triangles {
+		values := []float64{tri.Normal[0], tri.Normal[1], tri.Normal[2]}
+		for _, v := range tri.Vertices {
+			values = append(values, v[0], v[1], v[2])
+		}
+		for i, value := range values {
+			binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(float32(value)))
+		}
+		// attribute byte count, unused
+		binary.LittleEndian.PutUint16(buf[48:], 0)
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stlFacet is a triangle read from an STL file, along with the name
+// of the solid it belongs to (ascii only)
+type stlFacet struct {
+	Vertices [3][3]float64
+	Solid    string
+}
+
+func readSTLBinary(data []byte) ([]stlFacet, error) {
+	count := binary.LittleEndian.Uint32(data[80:84])
+	if len(data) < 84+int(count)*50 {
+		return nil, fmt.Errorf("stl: expected %d triangles, file is too short", count)
+	}
+	facets := make([]stlFacet, count)
+	for i := range facets {
+		offset := 84 + i*50 + 12 // skip the normal
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				bits := binary.LittleEndian.Uint32(data[offset+(j*3+k)*4:])
+				facets[i].Vertices[j][k] = float64(math.Float32frombits(bits))
+			}
+		}
+	}
+	return facets, nil
+}
+
+func readSTLASCII(data []byte) ([]stlFacet, error) {
+	var facets []stlFacet
+	var facet stlFacet
+	var solid string
+	count := 0
+	fields := strings.Fields(string(data))
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "solid":
+			// the name runs until the first facet
+			var name []string
+			for i+1 < len(fields) && fields[i+1] != "facet" && fields[i+1] != "endsolid" {
+				i++
+				name = append(name, fields[i])
+			}
+			solid = strings.Join(name, " ")
+		case "facet":
+			facet = stlFacet{Solid: solid}
+			count = 0
+		case "vertex":
+			if i+3 >= len(fields) || count > 2 {
+				return nil, fmt.Errorf("stl: malformed vertex")
+			}
+			for k := 0; k < 3; k++ {
+				value, err := strconv.ParseFloat(fields[i+1+k], 64)
+				if err != nil {
+					return nil, fmt.Errorf("stl: %v", err)
+				}
+				facet.Vertices[count][k] = value
+			}
+			count++
+			i += 3
+		case "endfacet":
+			if count != 3 {
+				return nil, fmt.Errorf("stl: facet with %d vertices", count)
+			}
+			facets = append(facets, facet)
+		}
+	}
+	return facets, nil
+}
+
+// ReadSTL parses a binary or ascii STL mesh into a linked node chain
+// and returns its first node. Coincident vertices are welded into
+// shared vertices, and adjacent coplanar triangles are merged back into
+// polygons (with holes where needed) so that each flat face of the
+// model becomes a single node. Faces from a named ascii solid are
+// tagged with its name. Like ReadOBJ, faces keep the file's winding
+// with their normals pointing out of the model, so call Flip on a face
+// before extruding it outwards
+func ReadSTL(r io.Reader) (*Node, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var facets []stlFacet
+	isBinary := len(data) >= 84 && len(data) == 84+int(binary.LittleEndian.Uint32(data[80:84]))*50
+	if !isBinary && bytes.HasPrefix(bytes.TrimSpace(data), []byte("solid")) {
+		facets, err = readSTLASCII(data)
+	} else if len(data) >= 84 {
+		facets, err = readSTLBinary(data)
+	} else {
+		err = fmt.Errorf("stl: file is too short")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(facets) == 0 {
+		return nil, fmt.Errorf("stl: no triangles")
+	}
+	node := stlToNodes(facets)
+	if node == nil {
+		return nil, fmt.Errorf("stl: every triangle is degenerate")
+	}
+	return node, nil
+}
+
+// LoadSTL reads an STL file, see ReadSTL
+func LoadSTL(filename string) (*Node, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadSTL(f)
+}
+
+func stlToNodes(facets []stlFacet) *Node {
+	// tolerances are relative to the size of the model
+	min, max := [3]float64{}, [3]float64{}
+	for k := 0; k < 3; k++ {
+		min[k], max[k] = math.Inf(1), math.Inf(-1)
+	}
+	for _, facet := range facets {
+		for _, v := range facet.Vertices {
+			for k := 0; k < 3; k++ {
+				min[k] = math.Min(min[k], v[k])
+				max[k] = math.Max(max[k], v[k])
+			}
+		}
+	}
+	size := math.Sqrt(math.Pow(max[0]-min[0], 2) + math.Pow(max[1]-min[1], 2) + math.Pow(max[2]-min[2], 2))
+	tolerance := size * 1e-6
+	if tolerance == 0 {
+		tolerance = 1e-9
+	}
+
+	// weld vertices, looking in the neighbouring grid cells as well so
+	// that points either side of a cell boundary still match
+	grid := map[[3]int64][]*Vertex3D{}
+	weld := func(p [3]float64) *Vertex3D {
+		var cell [3]int64
+		for k := 0; k < 3; k++ {
+			cell[k] = int64(math.Floor(p[k] / tolerance))
+		}
+		point := NewVertex3D(p[0], p[1], p[2])
+		for dx := int64(-1); dx <= 1; dx++ {
+			for dy := int64(-1); dy <= 1; dy++ {
+				for dz := int64(-1); dz <= 1; dz++ {
+					for _, v := range grid[[3]int64{cell[0] + dx, cell[1] + dy, cell[2] + dz}] {
+						if v.Distance(point) <= tolerance {
+							return v
+						}
+					}
+				}
+			}
+		}
+		grid[cell] = append(grid[cell], point)
+		return point
+	}
+
+	type triangle struct {
+		Vertices [3]*Vertex3D
+		Normal   *Vertex3D
+		Solid    string
+	}
+	var triangles []*triangle
+	for _, facet := range facets {
+		t := &triangle{Solid: facet.Solid}
+		for j, p := range facet.Vertices {
+			t.Vertices[j] = weld(p)
+		}
+		a, b, c := t.Vertices[0], t.Vertices[1], t.Vertices[2]
+		if a == b || b == c || a == c {
+			continue
+		}
+		normal := b.Subtract(a).Cross(c.Subtract(a))
+		if normal.Norm() == 0 {
+			continue
+		}
+		t.Normal = normal.Normalize()
+		triangles = append(triangles, t)
+	}
+
+	// index triangles by their directed edges
+	type edge [2]*Vertex3D
+	edges := map[edge]int{}
+	for i, t := range triangles {
+		for j := 0; j < 3; j++ {
+			edges[edge{t.Vertices[j], t.Vertices[(j+1)%3]}] = i
+		}
+	}
+
+	// grow regions of adjacent coplanar triangles
+	region := make([]int, len(triangles))
+	for i := range region {
+		region[i] = -1
+	}
+	var regions [][]int
+	for i := range triangles {
+		if region[i] != -1 {
+			continue
+		}
+		id := len(regions)
+		region[i] = id
+		members := []int{i}
+		seed := triangles[i]
+		for k := 0; k < len(members); k++ {
+			t := triangles[members[k]]
+			for j := 0; j < 3; j++ {
+				other, ok := edges[edge{t.Vertices[(j+1)%3], t.Vertices[j]}]
+				if !ok || region[other] != -1 {
+					continue
+				}
+				o := triangles[other]
+				coplanar := o.Normal.Dot(seed.Normal) > 1-1e-9
+				for _, v := range o.Vertices {
+					if math.Abs(v.Subtract(seed.Vertices[0]).Dot(seed.Normal)) > tolerance {
+						coplanar = false
+					}
+				}
+				if coplanar && o.Solid == seed.Solid {
+					region[other] = id
+					members = append(members, other)
+				}
+			}
+		}
+		regions = append(regions, members)
+	}
+
+	// count the regions each vertex is used by, so collinear vertices
+	// that nothing else depends on can be dropped from the outlines
+	users := map[*Vertex3D]map[int]bool{}
+	for i, t := range triangles {
+		for _, v := range t.Vertices {
+			if users[v] == nil {
+				users[v] = map[int]bool{}
+			}
+			users[v][region[i]] = true
+		}
+	}
+
+	var nodes Nodes
+	for _, members := range regions {
+		normal := triangles[members[0]].Normal
+		tag := triangles[members[0]].Solid
+		asTriangles := func() {
+			for _, i := range members {
+				t := triangles[i]
+				face := &Face3D{Vertices: []*Vertex3D{t.Vertices[0], t.Vertices[1], t.Vertices[2]}}
+				nodes = append(nodes, NewTaggedNode(tag, face))
+			}
+		}
+
+		// boundary edges are the ones not shared with another member
+		inRegion := map[edge]bool{}
+		for _, i := range members {
+			t := triangles[i]
+			for j := 0; j < 3; j++ {
+				inRegion[edge{t.Vertices[j], t.Vertices[(j+1)%3]}] = true
+			}
+		}
+		next := map[*Vertex3D]*Vertex3D{}
+		var starts []*Vertex3D
+		manifold := true
+		for _, i := range members {
+			t := triangles[i]
+			for j := 0; j < 3; j++ {
+				a, b := t.Vertices[j], t.Vertices[(j+1)%3]
+				if inRegion[edge{b, a}] {
+					continue
+				}
+				if _, ok := next[a]; ok {
+					manifold = false
+				}
+				next[a] = b
+				starts = append(starts, a)
+			}
+		}
+		if !manifold {
+			asTriangles()
+			continue
+		}
+
+		// walk the boundary into loops
+		var outers, holes []*Face3D
+		visited := map[*Vertex3D]bool{}
+		for _, start := range starts {
+			if visited[start] {
+				continue
+			}
+			loop := &Face3D{}
+			for v := start; !visited[v]; v = next[v] {
+				visited[v] = true
+				loop.Vertices = append(loop.Vertices, v)
+			}
+			loop.Vertices = removeCollinear(loop.Vertices, tolerance, func(v *Vertex3D) bool {
+				return len(users[v]) > 1
+			})
+			// the outline runs the same way as its triangles, holes the other way
+			area := NewVertex3D(0, 0, 0)
+			for i, v := range loop.Vertices {
+				area = area.Add(v.Cross(loop.Vertices[(i+1)%len(loop.Vertices)]))
+			}
+			if area.Dot(normal) > 0 {
+				outers = append(outers, loop)
+			} else {
+				holes = append(holes, loop)
+			}
+		}
+		if len(outers) != 1 || len(outers[0].Vertices) < 3 {
+			asTriangles()
+			continue
+		}
+		nodes = append(nodes, NewTaggedNode(tag, outers[0], holes...))
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+	nodes.LinkNodes()
+	return nodes[0]
+}
+
+// removeCollinear drops the vertices of a closed loop that lie on the
+// line between their neighbours, unless keep returns true for them
+func removeCollinear(vertices []*Vertex3D, tolerance float64, keep func(*Vertex3D) bool) []*Vertex3D {
+	changed := true
+	for changed && len(vertices) > 3 {
+		changed = false
+		for i := 0; i < len(vertices); i++ {
+			prev := vertices[(i+len(vertices)-1)%len(vertices)]
+			v := vertices[i]
+			next := vertices[(i+1)%len(vertices)]
+			if keep(v) {
+				continue
+			}
+			d := next.Subtract(prev)
+			length := d.Norm()
+			if length == 0 {
+				continue
+			}
+			// distance from v to the line through prev and next
+			if v.Subtract(prev).Cross(d).Norm()/length <= tolerance && v.Subtract(prev).Dot(d) > 0 && next.Subtract(v).Dot(d) > 0 {
+				vertices = append(vertices[:i:i], vertices[i+1:]...)
+				changed = true
+				break
+			}
+		}
+	}
+	return vertices
+}
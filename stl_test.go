@@ -0,0 +1,152 @@
+package toothpaste
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+func testCube() *Node {
+	bottom := NewNode(Square(1, 1).To3D())
+	top := bottom.Extrude(1)
+	top.Flip()
+	return bottom
+}
+
+func TestWriteSTLBinary(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testCube().WriteSTL(&buf, STLBinary); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	count := binary.LittleEndian.Uint32(data[80:84])
+	if count != 12 {
+		t.Errorf("Expected 12 triangles, got %v", count)
+	}
+	if len(data) != 84+50*12 {
+		t.Errorf("Expected %v bytes, got %v", 84+50*12, len(data))
+	}
+}
+
+func TestWriteSTLASCII(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testCube().WriteSTL(&buf, STLASCII); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "solid toothpaste\n") {
+		t.Errorf("Expected solid header, got %q", out[:20])
+	}
+	if c := strings.Count(out, "facet normal"); c != 12 {
+		t.Errorf("Expected 12 facets, got %v", c)
+	}
+}
+
+func TestSTLTriangleWinding(t *testing.T) {
+	for _, tri := range testCube().stlTriangles() {
+		a := NewVertex3D(tri.Vertices[0][0], tri.Vertices[0][1], tri.Vertices[0][2])
+		b := NewVertex3D(tri.Vertices[1][0], tri.Vertices[1][1], tri.Vertices[1][2])
+		c := NewVertex3D(tri.Vertices[2][0], tri.Vertices[2][1], tri.Vertices[2][2])
+		normal := NewVertex3D(tri.Normal[0], tri.Normal[1], tri.Normal[2])
+		if b.Subtract(a).Cross(c.Subtract(a)).Dot(normal) <= 0 {
+			t.Errorf("Expected triangle %v to face along %v", tri.Vertices, tri.Normal)
+		}
+	}
+}
+
+func TestReadSTL(t *testing.T) {
+	for _, format := range []STLFormat{STLBinary, STLASCII} {
+		var buf bytes.Buffer
+		if err := testCube().WriteSTL(&buf, format); err != nil {
+			t.Fatal(err)
+		}
+		node, err := ReadSTL(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes := node.Nodes()
+		if len(nodes) != 6 {
+			t.Fatalf("Expected 6 nodes, got %v", len(nodes))
+		}
+		if len(nodes.UniqueVertices()) != 8 {
+			t.Errorf("Expected 8 welded vertices, got %v", len(nodes.UniqueVertices()))
+		}
+		for _, n := range nodes {
+			if len(n.Outer.Vertices) != 4 {
+				t.Errorf("Expected quads, got %v vertices", len(n.Outer.Vertices))
+			}
+			if len(n.Connected()) != 4 {
+				t.Errorf("Expected 4 connected faces, got %v", len(n.Connected()))
+			}
+		}
+
+		// flipped, the top face extrudes up and away from the cube
+		var face *Node
+		for _, n := range nodes {
+			if n.Outer.Normal().Y > 0.99 {
+				face = n
+			}
+		}
+		if face == nil {
+			t.Fatalf("Expected a face pointing up out of the cube")
+		}
+		face.Flip()
+		if y := face.ExtrudeDrop(1).Outer.Centroid().Y; math.Abs(y-2) > 1e-6 {
+			t.Errorf("Expected the new top at y=2, got %v", y)
+		}
+	}
+}
+
+func TestReadSTLHoles(t *testing.T) {
+	hole := Square(1, 1)
+	hole.Translate(0.5, 0.5)
+	hole3D := hole.To3D()
+	hole3D.Flip()
+	bottom := NewNode(Square(2, 2).To3D(), hole3D)
+	bottom.Extrude(1).Flip()
+
+	var buf bytes.Buffer
+	if err := bottom.WriteSTL(&buf, STLBinary); err != nil {
+		t.Fatal(err)
+	}
+	node, err := ReadSTL(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := node.Nodes()
+	if len(nodes) != 10 {
+		t.Fatalf("Expected 10 nodes, got %v", len(nodes))
+	}
+	holes := 0
+	for _, n := range nodes {
+		holes += len(n.Inner)
+	}
+	if holes != 2 {
+		t.Errorf("Expected the top and bottom to have a hole each, got %v holes", holes)
+	}
+}
+
+func TestReadSTLDegenerate(t *testing.T) {
+	stl := `solid flat
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 2 0 0
+    endloop
+  endfacet
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 0 0 0
+      vertex 1 1 0
+    endloop
+  endfacet
+endsolid flat
+`
+	if _, err := ReadSTL(strings.NewReader(stl)); err == nil {
+		t.Errorf("Expected an error when every triangle is degenerate")
+	}
+}
@@ -0,0 +1,515 @@
+package toothpaste
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// svgMatrix is an affine transform in svg order, x' = a*x + c*y + e
+// and y' = b*x + d*y + f
+type svgMatrix [6]float64
+
+var svgIdentity = svgMatrix{1, 0, 0, 1, 0, 0}
+
+func (m svgMatrix) mul(o svgMatrix) svgMatrix {
+	return svgMatrix{
+		m[0]*o[0] + m[2]*o[1],
+		m[1]*o[0] + m[3]*o[1],
+		m[0]*o[2] + m[2]*o[3],
+		m[1]*o[2] + m[3]*o[3],
+		m[0]*o[4] + m[2]*o[5] + m[4],
+		m[1]*o[4] + m[3]*o[5] + m[5],
+	}
+}
+
+func (m svgMatrix) apply(v *Vertex2D) {
+	x, y := v.X, v.Y
+	v.X = m[0]*x + m[2]*y + m[4]
+	v.Y = m[1]*x + m[3]*y + m[5]
+}
+
+// scale is the average factor the transform scales lengths by
+func (m svgMatrix) scale() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+func parseSVGTransform(s string) (svgMatrix, error) {
+	m := svgIdentity
+	s = strings.TrimSpace(s)
+	for s != "" {
+		open := strings.Index(s, "(")
+		end := strings.Index(s, ")")
+		if open < 0 || end < open {
+			return m, fmt.Errorf("svg: malformed transform %q", s)
+		}
+		name := strings.Trim(strings.TrimSpace(s[:open]), ",")
+		args, err := parseSVGNumbers(s[open+1 : end])
+		if err != nil {
+			return m, err
+		}
+		s = strings.TrimLeft(s[end+1:], " \t\r\n,")
+		arg := func(i int, fallback float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return fallback
+		}
+		var t svgMatrix
+		switch name {
+		case "matrix":
+			if len(args) != 6 {
+				return m, fmt.Errorf("svg: matrix needs 6 values")
+			}
+			copy(t[:], args)
+		case "translate":
+			t = svgMatrix{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+		case "scale":
+			sx := arg(0, 1)
+			t = svgMatrix{sx, 0, 0, arg(1, sx), 0, 0}
+		case "rotate":
+			a := arg(0, 0) * math.Pi / 180
+			cx, cy := arg(1, 0), arg(2, 0)
+			t = svgMatrix{1, 0, 0, 1, cx, cy}.
+				mul(svgMatrix{math.Cos(a), math.Sin(a), -math.Sin(a), math.Cos(a), 0, 0}).
+				mul(svgMatrix{1, 0, 0, 1, -cx, -cy})
+		case "skewX":
+			t = svgMatrix{1, 0, math.Tan(arg(0, 0) * math.Pi / 180), 1, 0, 0}
+		case "skewY":
+			t = svgMatrix{1, math.Tan(arg(0, 0) * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			return m, fmt.Errorf("svg: unknown transform %q", name)
+		}
+		m = m.mul(t)
+	}
+	return m, nil
+}
+
+// svgScanner reads the numbers, flags and commands of path data
+type svgScanner struct {
+	s   string
+	pos int
+}
+
+func (sc *svgScanner) skip() {
+	for sc.pos < len(sc.s) && strings.IndexByte(" \t\r\n,", sc.s[sc.pos]) >= 0 {
+		sc.pos++
+	}
+}
+
+func (sc *svgScanner) done() bool {
+	sc.skip()
+	return sc.pos >= len(sc.s)
+}
+
+// command returns the next path command, or 0 when a number follows
+func (sc *svgScanner) command() byte {
+	sc.skip()
+	if sc.pos < len(sc.s) && strings.IndexByte("MmLlHhVvCcSsQqTtAaZz", sc.s[sc.pos]) >= 0 {
+		sc.pos++
+		return sc.s[sc.pos-1]
+	}
+	return 0
+}
+
+func (sc *svgScanner) number() (float64, error) {
+	sc.skip()
+	start := sc.pos
+	if sc.pos < len(sc.s) && (sc.s[sc.pos] == '-' || sc.s[sc.pos] == '+') {
+		sc.pos++
+	}
+	dot, exp := false, false
+	for sc.pos < len(sc.s) {
+		c := sc.s[sc.pos]
+		switch {
+		case c >= '0' && c <= '9':
+		case c == '.' && !dot && !exp:
+			dot = true
+		case (c == 'e' || c == 'E') && !exp && sc.pos > start:
+			exp = true
+			if sc.pos+1 < len(sc.s) && (sc.s[sc.pos+1] == '-' || sc.s[sc.pos+1] == '+') {
+				sc.pos++
+			}
+		default:
+			return sc.parse(start)
+		}
+		sc.pos++
+	}
+	return sc.parse(start)
+}
+
+func (sc *svgScanner) parse(start int) (float64, error) {
+	value, err := strconv.ParseFloat(sc.s[start:sc.pos], 64)
+	if err != nil {
+		return 0, fmt.Errorf("svg: bad number at %d in %q", start, sc.s)
+	}
+	return value, nil
+}
+
+// flag reads an arc flag, which may be written without a separator
+func (sc *svgScanner) flag() (bool, error) {
+	sc.skip()
+	if sc.pos < len(sc.s) && (sc.s[sc.pos] == '0' || sc.s[sc.pos] == '1') {
+		sc.pos++
+		return sc.s[sc.pos-1] == '1', nil
+	}
+	return false, fmt.Errorf("svg: bad arc flag at %d in %q", sc.pos, sc.s)
+}
+
+func parseSVGNumbers(s string) ([]float64, error) {
+	sc := &svgScanner{s: s}
+	var values []float64
+	for !sc.done() {
+		value, err := sc.number()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// parseSVGPath reads path data into a Path. Unclosed subpaths are
+// flattened as closed rings anyway, the same way svg fills them
+func parseSVGPath(d string) (*Path, error) {
+	sc := &svgScanner{s: d}
+	path := NewPath()
+	// the last control point, for the smooth curve commands
+	var lastCtrl *Vertex2D
+	var lastCmd byte
+
+	numbers := func(n int) ([]float64, error) {
+		values := make([]float64, n)
+		for i := range values {
+			value, err := sc.number()
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	}
+
+	cmd := sc.command()
+	if cmd != 'M' && cmd != 'm' {
+		if sc.done() {
+			return path, nil
+		}
+		return nil, fmt.Errorf("svg: path must start with a move, got %q", d)
+	}
+	for {
+		cur := path.cur
+		base := NewVertex2D(0, 0)
+		if cmd >= 'a' {
+			base = cur.Copy()
+		}
+		point := func(x, y float64) *Vertex2D {
+			return NewVertex2D(base.X+x, base.Y+y)
+		}
+		switch cmd {
+		case 'M', 'm':
+			v, err := numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			p := point(v[0], v[1])
+			path.MoveTo(p.X, p.Y)
+			// further pairs are implicit line commands
+			cmd = 'L' + (cmd - 'M')
+			lastCmd = cmd
+		case 'L', 'l':
+			v, err := numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			p := point(v[0], v[1])
+			path.LineTo(p.X, p.Y)
+		case 'H', 'h':
+			v, err := numbers(1)
+			if err != nil {
+				return nil, err
+			}
+			path.LineTo(base.X+v[0], cur.Y)
+		case 'V', 'v':
+			v, err := numbers(1)
+			if err != nil {
+				return nil, err
+			}
+			path.LineTo(cur.X, base.Y+v[0])
+		case 'C', 'c', 'S', 's':
+			var c1 *Vertex2D
+			var v []float64
+			var err error
+			if cmd == 'C' || cmd == 'c' {
+				if v, err = numbers(6); err != nil {
+					return nil, err
+				}
+				c1 = point(v[0], v[1])
+				v = v[2:]
+			} else {
+				if v, err = numbers(4); err != nil {
+					return nil, err
+				}
+				c1 = cur.Copy()
+				if lastCtrl != nil && strings.IndexByte("CcSs", lastCmd) >= 0 {
+					c1 = NewVertex2D(2*cur.X-lastCtrl.X, 2*cur.Y-lastCtrl.Y)
+				}
+			}
+			c2, end := point(v[0], v[1]), point(v[2], v[3])
+			path.CubicTo(c1.X, c1.Y, c2.X, c2.Y, end.X, end.Y)
+			lastCtrl = c2
+		case 'Q', 'q', 'T', 't':
+			var c *Vertex2D
+			var v []float64
+			var err error
+			if cmd == 'Q' || cmd == 'q' {
+				if v, err = numbers(4); err != nil {
+					return nil, err
+				}
+				c = point(v[0], v[1])
+				v = v[2:]
+			} else {
+				if v, err = numbers(2); err != nil {
+					return nil, err
+				}
+				c = cur.Copy()
+				if lastCtrl != nil && strings.IndexByte("QqTt", lastCmd) >= 0 {
+					c = NewVertex2D(2*cur.X-lastCtrl.X, 2*cur.Y-lastCtrl.Y)
+				}
+			}
+			end := point(v[0], v[1])
+			path.QuadTo(c.X, c.Y, end.X, end.Y)
+			lastCtrl = c
+		case 'A', 'a':
+			v, err := numbers(3)
+			if err != nil {
+				return nil, err
+			}
+			large, err := sc.flag()
+			if err != nil {
+				return nil, err
+			}
+			sweep, err := sc.flag()
+			if err != nil {
+				return nil, err
+			}
+			e, err := numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			end := point(e[0], e[1])
+			path.ArcTo(v[0], v[1], v[2], large, sweep, end.X, end.Y)
+		case 'Z', 'z':
+			path.Close()
+		}
+		if cmd != 'M' && cmd != 'm' {
+			lastCmd = cmd
+		}
+		if strings.IndexByte("CcSsQqTt", cmd) < 0 {
+			lastCtrl = nil
+		}
+
+		if sc.done() {
+			break
+		}
+		if next := sc.command(); next != 0 {
+			cmd = next
+		} else if cmd == 'Z' || cmd == 'z' {
+			return nil, fmt.Errorf("svg: unexpected number after close in %q", d)
+		}
+	}
+	return path, nil
+}
+
+// svgLength parses a length attribute, ignoring its unit
+func svgLength(s string) float64 {
+	s = strings.TrimSpace(s)
+	end := len(s)
+	for end > 0 && (s[end-1] < '0' || s[end-1] > '9') && s[end-1] != '.' {
+		end--
+	}
+	value, _ := strconv.ParseFloat(s[:end], 64)
+	return value
+}
+
+func svgAttr(e xml.StartElement, name string) string {
+	for _, attr := range e.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// svgEllipse flattens an ellipse into a closed ring
+func svgEllipse(cx, cy, rx, ry, tolerance float64) []*Vertex2D {
+	arc := &ellipseArc{CX: cx, CY: cy, RX: rx, RY: ry, Sweep: 2 * math.Pi}
+	segments := int(math.Max(8, float64(arc.segments(tolerance))))
+	points := arc.flatten(segments)
+	// the last point repeats the start
+	return points[:len(points)-1]
+}
+
+// svgRect flattens a rectangle, with rounded corners if rx or ry is set
+func svgRect(x, y, w, h, rx, ry, tolerance float64) []*Vertex2D {
+	if rx == 0 && ry == 0 {
+		return []*Vertex2D{NewVertex2D(x, y), NewVertex2D(x+w, y), NewVertex2D(x+w, y+h), NewVertex2D(x, y+h)}
+	}
+	if rx == 0 {
+		rx = ry
+	} else if ry == 0 {
+		ry = rx
+	}
+	rx, ry = math.Min(rx, w/2), math.Min(ry, h/2)
+	corners := [][3]float64{
+		{x + w - rx, y + ry, -math.Pi / 2},
+		{x + w - rx, y + h - ry, 0},
+		{x + rx, y + h - ry, math.Pi / 2},
+		{x + rx, y + ry, math.Pi},
+	}
+	var ring []*Vertex2D
+	for _, c := range corners {
+		arc := &ellipseArc{CX: c[0], CY: c[1], RX: rx, RY: ry, Start: c[2], Sweep: math.Pi / 2}
+		ring = append(ring, arc.point(c[2]))
+		ring = append(ring, arc.flatten(arc.segments(tolerance))...)
+	}
+	return ring
+}
+
+// ParseSVG reads the filled shapes of an svg document. Paths, polygons,
+// polylines, rects, circles and ellipses are flattened so that curves
+// stay within tolerance of the original, and group and element
+// transforms are applied. The subpaths of each element are sorted into
+// outlines and holes by how they nest. Since svg has y pointing down,
+// the result is mirrored vertically inside the viewBox (or the height of
+// the document) so that it appears the same way up
+func ParseSVG(r io.Reader, tolerance float64) ([]*Shape2D, error) {
+	if tolerance <= 0 {
+		return nil, fmt.Errorf("svg: tolerance must be positive")
+	}
+	decoder := xml.NewDecoder(r)
+	transforms := []svgMatrix{svgIdentity}
+	skipDepth := 0
+	flipY, hasFlip := 0.0, false
+	var elements [][]*Face2D
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch e := token.(type) {
+		case xml.StartElement:
+			if skipDepth > 0 {
+				skipDepth++
+				continue
+			}
+			switch e.Name.Local {
+			case "defs", "clipPath", "mask", "symbol", "pattern", "marker":
+				skipDepth = 1
+				continue
+			case "svg":
+				if !hasFlip {
+					if values, err := parseSVGNumbers(svgAttr(e, "viewBox")); err == nil && len(values) == 4 {
+						flipY, hasFlip = 2*values[1]+values[3], true
+					} else if h := svgAttr(e, "height"); h != "" {
+						flipY, hasFlip = svgLength(h), true
+					}
+				}
+			}
+
+			m := transforms[len(transforms)-1]
+			if t := svgAttr(e, "transform"); t != "" {
+				local, err := parseSVGTransform(t)
+				if err != nil {
+					return nil, err
+				}
+				m = m.mul(local)
+			}
+			transforms = append(transforms, m)
+
+			// flatten in local coordinates, so scale the tolerance too
+			local := tolerance
+			if s := m.scale(); s > 0 {
+				local /= s
+			}
+			var rings [][]*Vertex2D
+			switch e.Name.Local {
+			case "path":
+				var path *Path
+				if path, err = parseSVGPath(svgAttr(e, "d")); err == nil {
+					rings = path.rings(local, 0)
+				}
+			case "polygon", "polyline":
+				var values []float64
+				values, err = parseSVGNumbers(svgAttr(e, "points"))
+				if err == nil {
+					rings = [][]*Vertex2D{NewFace2D(values[:len(values)/2*2]...).Vertices}
+				}
+			case "rect":
+				rings = [][]*Vertex2D{svgRect(
+					svgLength(svgAttr(e, "x")), svgLength(svgAttr(e, "y")),
+					svgLength(svgAttr(e, "width")), svgLength(svgAttr(e, "height")),
+					svgLength(svgAttr(e, "rx")), svgLength(svgAttr(e, "ry")), local,
+				)}
+			case "circle":
+				radius := svgLength(svgAttr(e, "r"))
+				rings = [][]*Vertex2D{svgEllipse(svgLength(svgAttr(e, "cx")), svgLength(svgAttr(e, "cy")), radius, radius, local)}
+			case "ellipse":
+				rings = [][]*Vertex2D{svgEllipse(
+					svgLength(svgAttr(e, "cx")), svgLength(svgAttr(e, "cy")),
+					svgLength(svgAttr(e, "rx")), svgLength(svgAttr(e, "ry")), local,
+				)}
+			}
+			if err != nil {
+				return nil, err
+			}
+			var faces []*Face2D
+			for _, ring := range rings {
+				for _, v := range ring {
+					m.apply(v)
+				}
+				faces = append(faces, &Face2D{Vertices: cleanRing(ring, 0)})
+			}
+			if len(faces) > 0 {
+				elements = append(elements, faces)
+			}
+		case xml.EndElement:
+			if skipDepth > 0 {
+				skipDepth--
+				continue
+			}
+			if len(transforms) > 1 {
+				transforms = transforms[:len(transforms)-1]
+			}
+		}
+	}
+
+	var shapes []*Shape2D
+	for _, faces := range elements {
+		for _, f := range faces {
+			for _, v := range f.Vertices {
+				v.Y = flipY - v.Y
+			}
+		}
+		shapes = append(shapes, shapesFromRings(faces)...)
+	}
+	return shapes, nil
+}
+
+// LoadSVG reads an svg file, see ParseSVG
+func LoadSVG(filename string, tolerance float64) ([]*Shape2D, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseSVG(f, tolerance)
+}
@@ -0,0 +1,93 @@
+package toothpaste
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseSVGHoles(t *testing.T) {
+	doc := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10">
+		<path d="M0 0H10V10H0Z M2 2v6h6V2z"/>
+	</svg>`
+	shapes, err := ParseSVG(strings.NewReader(doc), 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shapes) != 1 {
+		t.Fatalf("Expected 1 shape, got %v", len(shapes))
+	}
+	if len(shapes[0].Holes) != 1 {
+		t.Fatalf("Expected 1 hole, got %v", len(shapes[0].Holes))
+	}
+	if area := signedArea(shapes[0].Outer.Vertices); area != 100 {
+		t.Errorf("Expected a counter-clockwise outer of area 100, got %v", area)
+	}
+	if area := signedArea(shapes[0].Holes[0].Vertices); area != -36 {
+		t.Errorf("Expected a clockwise hole of area 36, got %v", area)
+	}
+}
+
+func TestParseSVGElements(t *testing.T) {
+	doc := `<svg xmlns="http://www.w3.org/2000/svg" width="100" height="100">
+		<defs><rect width="5" height="5"/></defs>
+		<g transform="translate(10 20)">
+			<rect x="0" y="0" width="4" height="2"/>
+			<circle cx="50" cy="50" r="10" transform="scale(2)"/>
+		</g>
+		<path d="M0,0 Q5,10 10,0 A5 5 0 0 1 0 0"/>
+	</svg>`
+	shapes, err := ParseSVG(strings.NewReader(doc), 0.001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shapes) != 3 {
+		t.Fatalf("Expected 3 shapes, got %v", len(shapes))
+	}
+
+	rect := shapes[0].Outer
+	minX, maxY := rect.Vertices[0].X, rect.Vertices[0].Y
+	for _, v := range rect.Vertices {
+		minX = math.Min(minX, v.X)
+		maxY = math.Max(maxY, v.Y)
+	}
+	if minX != 10 || maxY != 80 {
+		t.Errorf("Expected the rect to be translated and flipped, got x %v, y %v", minX, maxY)
+	}
+
+	area := signedArea(shapes[1].Outer.Vertices)
+	if expected := math.Pi * 400; math.Abs(area-expected) > 1 {
+		t.Errorf("Expected circle area %v, got %v", expected, area)
+	}
+}
+
+func TestParseSVGViewBoxFlip(t *testing.T) {
+	// mirrored inside the viewBox, so it keeps its place in y
+	doc := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 -20 10 10">
+		<rect x="0" y="-19" width="2" height="3"/>
+	</svg>`
+	shapes, err := ParseSVG(strings.NewReader(doc), 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, v := range shapes[0].Outer.Vertices {
+		minY = math.Min(minY, v.Y)
+		maxY = math.Max(maxY, v.Y)
+	}
+	if minY != -14 || maxY != -11 {
+		t.Errorf("Expected y from -14 to -11, got %v to %v", minY, maxY)
+	}
+}
+
+func TestParseSVGErrors(t *testing.T) {
+	for _, doc := range []string{
+		`<svg><path d="L 1 1"/></svg>`,
+		`<svg><path d="M 0 0 L 1"/></svg>`,
+		`<svg><rect transform="spin(3)"/></svg>`,
+	} {
+		if _, err := ParseSVG(strings.NewReader(doc), 0.1); err == nil {
+			t.Errorf("Expected an error for %v", doc)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package toothpaste
+
+import (
+	"fmt"
+	"math"
+)
+
+type SweepOptions struct {
+	Twist  func(t float64) float64 // degrees the profile is turned by at t, from 0 at the start of the path to 1 at the end
+	Scale  func(t float64) float64 // size of the profile at t, 1 if not given
+	Closed bool                    // the path loops back round to its start, so there are no caps
+}
+
+// Sweep moves the face along a path of points, see Shape2D.Sweep
+func (f *Face2D) Sweep(path []*Vertex3D, opts ...SweepOptions) *Node {
+	return NewShape2D(f).Sweep(path, opts...)
+}
+
+// Sweep moves the shape along a path of points to make a solid, like a
+// pipe or a moulding. The shape's origin follows the path and its X and
+// Y axes turn with it as little as possible (rotation minimizing
+// frames), and at each bend the shape is stretched so that the walls
+// keep their thickness. The sides swept by each edge are tagged
+// "side<i>", counting round the outer counter-clockwise and then the
+// holes, followed by caps tagged "start" and "end" unless the path is
+// closed
+func (s *Shape2D) Sweep(path []*Vertex3D, opts ...SweepOptions) *Node {
+	var opt SweepOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	var points []*Vertex3D
+	for _, p := range path {
+		if len(points) == 0 || points[len(points)-1].Distance(p) > 0 {
+			points = append(points, p)
+		}
+	}
+	if opt.Closed && len(points) > 2 && points[0].Distance(points[len(points)-1]) == 0 {
+		points = points[:len(points)-1]
+	}
+	if len(points) < 2 {
+		return nil
+	}
+	n := len(points)
+	closed := opt.Closed && n > 2
+
+	// how far along the path each point is, from 0 to 1
+	along := make([]float64, n)
+	for i := 1; i < n; i++ {
+		along[i] = along[i-1] + points[i].Distance(points[i-1])
+	}
+	total := along[n-1]
+	if closed {
+		total += points[n-1].Distance(points[0])
+	}
+	for i := range along {
+		along[i] /= total
+	}
+
+	// the direction of each segment, and at each point the direction
+	// half way between the segments either side
+	dirs := make([]*Vertex3D, n)
+	for i := range points {
+		if !closed && i == n-1 {
+			dirs[i] = dirs[i-1]
+			continue
+		}
+		dirs[i] = points[(i+1)%n].Subtract(points[i]).Normalize()
+	}
+	tangents := make([]*Vertex3D, n)
+	for i := range points {
+		in := dirs[(i+n-1)%n]
+		if !closed && i == 0 {
+			in = dirs[0]
+		}
+		tangent := in.Add(dirs[i])
+		if tangent.Norm() < 1e-9 {
+			// doubling back on itself, there's no good way through
+			tangent = dirs[i]
+		}
+		tangents[i] = tangent.Normalize()
+	}
+
+	// rotation minimizing frames by double reflection (Wang et al.)
+	frames := make([]*Vertex3D, n+1)
+	frames[0] = perpendicular(tangents[0])
+	for i := 0; i < n; i++ {
+		if i == n-1 && !closed {
+			break
+		}
+		j := (i + 1) % n
+		v1 := points[j].Subtract(points[i])
+		r := reflect3D(frames[i], v1)
+		t := reflect3D(tangents[i], v1)
+		frames[i+1] = reflect3D(r, tangents[j].Subtract(t))
+	}
+	// going round a loop can leave the frame turned from where it
+	// started, so that is spread along the path as extra twist
+	correction := 0.0
+	if closed {
+		r0, rn := frames[0], frames[n]
+		correction = math.Atan2(rn.Cross(r0).Dot(tangents[0]), rn.Dot(r0)) * 180 / math.Pi
+	}
+
+	rings := orientedRings(s)
+	// the vertices of every ring of the shape, at every point
+	placed := make([][][]*Vertex3D, n)
+	for i, p := range points {
+		t := along[i]
+		twist := correction * t
+		if opt.Twist != nil {
+			twist += opt.Twist(t)
+		}
+		scale := 1.0
+		if opt.Scale != nil {
+			scale = opt.Scale(t)
+		}
+		angle := twist * math.Pi / 180
+		tangent := tangents[i]
+		u := frames[i]
+		v := tangent.Cross(u)
+		u, v = rotateFrame(u, v, angle)
+
+		// stretch across the bend so that the walls stay parallel
+		in := dirs[(i+n-1)%n]
+		if !closed && i == 0 {
+			in = dirs[0]
+		}
+		bend := dirs[i].Subtract(in)
+		stretch := 1.0
+		if cos := in.Dot(tangent); bend.Norm() > 1e-9 && cos > 1e-9 {
+			bend = bend.Normalize()
+			stretch = 1 / cos
+		}
+
+		placed[i] = make([][]*Vertex3D, len(rings))
+		for k, ring := range rings {
+			for _, vertex := range ring {
+				offset := NewVertex3D(
+					(u.X*vertex.X+v.X*vertex.Y)*scale,
+					(u.Y*vertex.X+v.Y*vertex.Y)*scale,
+					(u.Z*vertex.X+v.Z*vertex.Y)*scale,
+				)
+				if stretch != 1 {
+					amount := offset.Dot(bend) * (stretch - 1)
+					offset = offset.Add(NewVertex3D(bend.X*amount, bend.Y*amount, bend.Z*amount))
+				}
+				placed[i][k] = append(placed[i][k], NewLabelledVertex3DWithUV(
+					p.X+offset.X, p.Y+offset.Y, p.Z+offset.Z, vertex.U, vertex.V, vertex.Label,
+				))
+			}
+		}
+	}
+
+	var nodes []*Node
+	edge := 0
+	for k, ring := range rings {
+		for e := range ring {
+			tag := fmt.Sprintf("side%d", edge)
+			edge++
+			segments := n - 1
+			if closed {
+				segments = n
+			}
+			for i := 0; i < segments; i++ {
+				a, b := placed[i][k], placed[(i+1)%n][k]
+				// the right of each edge faces out
+				face := &Face3D{Vertices: []*Vertex3D{
+					a[e], a[(e+1)%len(ring)], b[(e+1)%len(ring)], b[e],
+				}}
+				nodes = append(nodes, NewTaggedNode(tag, face))
+			}
+		}
+	}
+
+	if !closed {
+		for _, end := range []int{0, n - 1} {
+			var faces []*Face3D
+			for _, ring := range placed[end] {
+				face := &Face3D{Vertices: append([]*Vertex3D{}, ring...)}
+				// the start looks back along the path
+				if end == 0 {
+					face.Flip()
+				}
+				faces = append(faces, face)
+			}
+			tag := "start"
+			if end != 0 {
+				tag = "end"
+			}
+			nodes = append(nodes, NewTaggedNode(tag, faces[0], faces[1:]...))
+		}
+	}
+	NewLinkedNodes(nodes...)
+	return nodes[0]
+}
+
+// perpendicular returns a unit vector at right angles to v
+func perpendicular(v *Vertex3D) *Vertex3D {
+	// crossed with the axis it is least like
+	x, y, z := math.Abs(v.X), math.Abs(v.Y), math.Abs(v.Z)
+	axis := NewVertex3D(0, 0, 1)
+	if x <= y && x <= z {
+		axis = NewVertex3D(1, 0, 0)
+	} else if y <= z {
+		axis = NewVertex3D(0, 1, 0)
+	}
+	return v.Cross(axis).Normalize()
+}
+
+// reflect3D reflects v in the plane at right angles to normal
+func reflect3D(v, normal *Vertex3D) *Vertex3D {
+	c := normal.Dot(normal)
+	if c == 0 {
+		return v.Copy()
+	}
+	k := 2 * normal.Dot(v) / c
+	return NewVertex3D(v.X-normal.X*k, v.Y-normal.Y*k, v.Z-normal.Z*k)
+}
+
+// rotateFrame turns the axes u and v by angle radians, from u towards v
+func rotateFrame(u, v *Vertex3D, angle float64) (*Vertex3D, *Vertex3D) {
+	c, s := math.Cos(angle), math.Sin(angle)
+	return NewVertex3D(u.X*c+v.X*s, u.Y*c+v.Y*s, u.Z*c+v.Z*s),
+		NewVertex3D(v.X*c-u.X*s, v.Y*c-u.Y*s, v.Z*c-u.Z*s)
+}
+
+// Spline3D evaluates a clamped cubic B-spline through 3D control
+// points like Path.SplineTo, with segments points per span, for use as
+// a path to sweep along
+func Spline3D(controls []*Vertex3D, segments int) []*Vertex3D {
+	n := len(controls)
+	spline := newBSpline(n)
+	if spline == nil || segments < 1 {
+		return append([]*Vertex3D{}, controls...)
+	}
+	degree, spans := spline.degree, spline.spans
+	eval := func(span int, t float64) *Vertex3D {
+		point := NewVertex3D(0, 0, 0)
+		for j, w := range spline.weights(span, t) {
+			c := controls[span-degree+j]
+			point.X += w * c.X
+			point.Y += w * c.Y
+			point.Z += w * c.Z
+		}
+		return point
+	}
+
+	points := []*Vertex3D{controls[0].Copy()}
+	for s := 0; s < spans; s++ {
+		for i := 1; i <= segments; i++ {
+			points = append(points, eval(s+degree, float64(s)+float64(i)/float64(segments)))
+		}
+	}
+	points[len(points)-1] = controls[n-1].Copy()
+	return points
+}
@@ -0,0 +1,87 @@
+package toothpaste
+
+import (
+	"math"
+	"testing"
+)
+
+func centredSquare() *Face2D {
+	return NewFace2D(-0.5, -0.5, 0.5, -0.5, 0.5, 0.5, -0.5, 0.5)
+}
+
+func TestSweep(t *testing.T) {
+	n := centredSquare().Sweep([]*Vertex3D{NewVertex3D(0, 0, 0), NewVertex3D(0, 0, 4)})
+	if count := len(n.Nodes()); count != 6 {
+		t.Fatalf("Expected 6 nodes, got %v", count)
+	}
+	if v := volume(n); math.Abs(v-4) > 1e-9 {
+		t.Errorf("Expected volume 4, got %v", v)
+	}
+	if n.Get("start") == nil || n.Get("end") == nil || n.Get("side3") == nil {
+		t.Errorf("Expected tagged caps and sides")
+	}
+
+	// round a corner, the walls keep their thickness
+	path := []*Vertex3D{NewVertex3D(0, 0, 0), NewVertex3D(4, 0, 0), NewVertex3D(4, 4, 0)}
+	n = centredSquare().Sweep(path)
+	if v := volume(n); math.Abs(v-8) > 1e-9 {
+		t.Errorf("Expected volume 8, got %v", v)
+	}
+	for _, v := range n.Nodes()[1].Outer.Vertices {
+		if math.Abs(math.Abs(v.Z)-0.5) > 1e-9 {
+			t.Errorf("Expected the profile to stay level, got %v", v)
+		}
+	}
+
+	// a loop has no caps
+	path = append(path, NewVertex3D(0, 4, 0))
+	n = centredSquare().Sweep(path, SweepOptions{Closed: true})
+	if count := len(n.Nodes()); count != 16 {
+		t.Fatalf("Expected 16 nodes, got %v", count)
+	}
+	if v := volume(n); math.Abs(v-16) > 1e-9 {
+		t.Errorf("Expected volume 16, got %v", v)
+	}
+}
+
+func TestSweepTwistScale(t *testing.T) {
+	path := []*Vertex3D{NewVertex3D(0, 0, 0), NewVertex3D(0, 0, 2), NewVertex3D(0, 0, 4)}
+	n := centredSquare().Sweep(path, SweepOptions{
+		Twist: func(t float64) float64 { return 45 * t },
+		Scale: func(t float64) float64 { return 1 - t/2 },
+	})
+	start, end := n.Get("start").Outer, n.Get("end").Outer
+	if math.Abs(start.Area()-1) > 1e-9 || math.Abs(end.Area()-0.25) > 1e-9 {
+		t.Errorf("Expected the profile to shrink from 1 to 0.25, got %v and %v", start.Area(), end.Area())
+	}
+	// turned by 45 degrees, the corners line up with the axes
+	for _, v := range end.Vertices {
+		if math.Abs(v.X) > 1e-9 && math.Abs(v.Y) > 1e-9 {
+			t.Errorf("Expected the end to be turned, got %v", v)
+		}
+	}
+
+	// holes go through
+	s := NewShape2D(centredSquare(), NewFace2D(-0.25, -0.25, 0.25, -0.25, 0.25, 0.25, -0.25, 0.25))
+	n = s.Sweep(path)
+	if count := len(n.Nodes()); count != 8*2+2 {
+		t.Errorf("Expected 18 nodes, got %v", count)
+	}
+	if len(n.Get("start").Inner) != 1 {
+		t.Errorf("Expected the cap to have a hole")
+	}
+}
+
+func TestSpline3D(t *testing.T) {
+	controls := []*Vertex3D{NewVertex3D(0, 0, 0), NewVertex3D(1, 1, 0), NewVertex3D(2, 1, 1), NewVertex3D(3, 0, 1), NewVertex3D(4, 0, 0)}
+	points := Spline3D(controls, 8)
+	if len(points) != 1+2*8 {
+		t.Fatalf("Expected 17 points, got %v", len(points))
+	}
+	if !points[0].Equals(controls[0]) || !points[len(points)-1].Equals(controls[4]) {
+		t.Errorf("Expected the spline to start and end on the controls")
+	}
+	if n := centredSquare().Sweep(points); len(n.Nodes()) != 4*16+2 {
+		t.Errorf("Expected 66 nodes, got %v", len(n.Nodes()))
+	}
+}
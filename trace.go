@@ -0,0 +1,179 @@
+package toothpaste
+
+import (
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+	"sort"
+)
+
+type TraceChannel int
+
+const (
+	TraceAlpha     TraceChannel = iota // opaque pixels are inside
+	TraceLuminance                     // dark pixels are inside, as if drawn on white
+)
+
+type TraceOptions struct {
+	Channel   TraceChannel
+	Threshold float64 // from 0 to 1, defaults to 0.5
+	Invert    bool    // trace the empty parts of the image instead
+	Tolerance float64 // how far simplifying may move the outline, defaults to half a pixel
+}
+
+// TraceImage finds the outlines of the filled parts of an image with
+// marching squares, and sorts them into shapes with holes. The image is
+// scaled to w by h, with the bottom left corner at the origin and the
+// right way up
+func TraceImage(img image.Image, w, h float64, opts ...TraceOptions) []*Shape2D {
+	var opt TraceOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Threshold <= 0 || opt.Threshold >= 1 {
+		opt.Threshold = 0.5
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil
+	}
+	scaleX, scaleY := w/float64(width), h/float64(height)
+	if opt.Tolerance <= 0 {
+		opt.Tolerance = 0.5 * math.Min(scaleX, scaleY)
+	}
+
+	// samples at the pixel centres, with a border of empty samples around
+	// the outside so that every contour closes
+	cols, rows := width+2, height+2
+	values := make([]float64, cols*rows)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			var v float64
+			if opt.Channel == TraceLuminance {
+				// premultiplied, so this is the colour over white
+				v = 1 - (0.299*float64(r)+0.587*float64(g)+0.114*float64(b)+65535-float64(a))/65535
+			} else {
+				v = float64(a) / 65535
+			}
+			if opt.Invert {
+				v = 1 - v
+			}
+			values[(y+1)*cols+x+1] = v
+		}
+	}
+	inside := func(x, y int) bool {
+		return values[y*cols+x] >= opt.Threshold
+	}
+
+	// each crossing is numbered by the sample edge it's on, horizontal
+	// edges even and vertical odd
+	points := map[int]*Vertex2D{}
+	crossing := func(x0, y0, x1, y1 int) int {
+		id := (y0*cols + x0) * 2
+		if y1 != y0 {
+			id++
+		}
+		if _, ok := points[id]; !ok {
+			v0, v1 := values[y0*cols+x0], values[y1*cols+x1]
+			t := 0.5
+			if v1 != v0 {
+				t = (opt.Threshold - v0) / (v1 - v0)
+			}
+			// back to image pixels, then flipped into the world
+			px := lerp(float64(x0), float64(x1), t) - 0.5
+			py := lerp(float64(y0), float64(y1), t) - 0.5
+			points[id] = NewVertex2D(px*scaleX, h-py*scaleY)
+		}
+		return id
+	}
+
+	next := map[int]int{}
+	for y := 0; y+1 < rows; y++ {
+		for x := 0; x+1 < cols; x++ {
+			// corners in order round the cell, and the edges between them
+			corners := [4][2]int{{x, y}, {x + 1, y}, {x + 1, y + 1}, {x, y + 1}}
+			var in [4]bool
+			count := 0
+			for i, c := range corners {
+				in[i] = inside(c[0], c[1])
+				if in[i] {
+					count++
+				}
+			}
+			if count == 0 || count == 4 {
+				continue
+			}
+			edge := func(k int) int {
+				a, b := corners[k%4], corners[(k+1)%4]
+				if a[0] > b[0] || a[1] > b[1] {
+					a, b = b, a
+				}
+				return crossing(a[0], a[1], b[0], b[1])
+			}
+			// the contour comes in over an edge going from outside to
+			// inside, and leaves over the next edge going back out. Where
+			// opposite corners match, the average decides whether the
+			// middle of the cell joins the inside corners together
+			joined := (values[y*cols+x]+values[y*cols+x+1]+values[(y+1)*cols+x]+values[(y+1)*cols+x+1])/4 >= opt.Threshold
+			for k := 0; k < 4; k++ {
+				if in[k] || !in[(k+1)%4] {
+					continue
+				}
+				out := k + 1
+				if count == 2 && joined && in[(k+3)%4] {
+					out = k + 3
+				}
+				for !in[out%4] || in[(out+1)%4] {
+					out++
+				}
+				next[edge(k)] = edge(out)
+			}
+		}
+	}
+
+	// in order, so the same image always gives the same outlines
+	starts := make([]int, 0, len(next))
+	for id := range next {
+		starts = append(starts, id)
+	}
+	sort.Ints(starts)
+	var rings []*Face2D
+	for _, start := range starts {
+		if _, ok := next[start]; !ok {
+			continue
+		}
+		ring := &Face2D{}
+		for id := start; ; {
+			ring.Vertices = append(ring.Vertices, points[id])
+			to, ok := next[id]
+			delete(next, id)
+			if !ok || to == start {
+				break
+			}
+			id = to
+		}
+		if ring = ring.Simplify(opt.Tolerance); len(ring.Vertices) >= 3 && signedArea(ring.Vertices) != 0 {
+			rings = append(rings, ring)
+		}
+	}
+	return shapesFromRings(rings)
+}
+
+// LoadImage reads a png, jpeg or gif file and traces it, see TraceImage
+func LoadImage(filename string, w, h float64, opts ...TraceOptions) ([]*Shape2D, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	return TraceImage(img, w, h, opts...), nil
+}
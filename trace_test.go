@@ -0,0 +1,85 @@
+package toothpaste
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// frame draws a filled square with a square hole in it
+func frame(background, fill color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			img.Set(x, y, background)
+			if x >= 5 && x < 15 && y >= 5 && y < 15 && !(x >= 8 && x < 12 && y >= 8 && y < 12) {
+				img.Set(x, y, fill)
+			}
+		}
+	}
+	return img
+}
+
+func TestTraceImage(t *testing.T) {
+	img := frame(color.Transparent, color.Black)
+	shapes := TraceImage(img, 40, 40, TraceOptions{Tolerance: 1e-9})
+	if len(shapes) != 1 || len(shapes[0].Holes) != 1 {
+		t.Fatalf("Expected 1 shape with a hole, got %v", shapes)
+	}
+	// twice the size of the pixels, with the corners cut across
+	if area := shapesArea(shapes); math.Abs(area-4*(99.5-15.5)) > 1e-9 {
+		t.Errorf("Expected area 336, got %v", area)
+	}
+	// simplifying only moves the outline by half a pixel
+	shapes = TraceImage(img, 40, 40)
+	if len(shapes) != 1 || len(shapes[0].Outer.Vertices) != 4 {
+		t.Fatalf("Expected the outer to simplify to 4 corners, got %v", shapes)
+	}
+	if area := shapesArea(shapes); math.Abs(area-336) > 4*40*0.5 {
+		t.Errorf("Expected area about 336, got %v", area)
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	for _, v := range shapes[0].Outer.Vertices {
+		minX, minY = math.Min(minX, v.X), math.Min(minY, v.Y)
+	}
+	if math.Abs(minX-10) > 1e-9 || math.Abs(minY-10) > 1e-9 {
+		t.Errorf("Expected the outline to start at 10, 10, got %v, %v", minX, minY)
+	}
+
+	// dark on white traced by luminance
+	img = frame(color.White, color.Black)
+	if shapes := TraceImage(img, 20, 20, TraceOptions{Channel: TraceLuminance}); len(shapes) != 1 || len(shapes[0].Holes) != 1 {
+		t.Errorf("Expected 1 shape with a hole, got %v", shapes)
+	}
+	// inverted, the background with a hole and the middle on its own
+	if shapes := TraceImage(img, 20, 20, TraceOptions{Channel: TraceLuminance, Invert: true}); len(shapes) != 2 {
+		t.Errorf("Expected 2 shapes, got %v", shapes)
+	}
+}
+
+func TestLoadImage(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "frame.png")
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, frame(color.Transparent, color.Black)); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	shapes, err := LoadImage(filename, 20, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shapes) != 1 || len(shapes[0].Holes) != 1 {
+		t.Errorf("Expected 1 shape with a hole, got %v", shapes)
+	}
+	if _, err := LoadImage(filepath.Join(t.TempDir(), "missing.png"), 20, 20); err == nil {
+		t.Errorf("Expected an error for a missing file")
+	}
+}
@@ -0,0 +1,519 @@
+package toothpaste
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+type ValidationProblem int
+
+const (
+	TooFewVertices ValidationProblem = iota
+	DuplicateVertex
+	CollinearVertex
+	SelfIntersection
+	NonPlanar
+	WrongOrientation
+	HoleCrossesOuter
+	HoleOutsideOuter
+	HolesOverlap
+)
+
+func (p ValidationProblem) String() string {
+	switch p {
+	case TooFewVertices:
+		return "too few vertices"
+	case DuplicateVertex:
+		return "duplicate vertex"
+	case CollinearVertex:
+		return "collinear vertex"
+	case SelfIntersection:
+		return "self intersection"
+	case NonPlanar:
+		return "vertex off the plane"
+	case WrongOrientation:
+		return "hole wound the same way as the outer"
+	case HoleCrossesOuter:
+		return "hole crosses the outer"
+	case HoleOutsideOuter:
+		return "hole outside the outer"
+	case HolesOverlap:
+		return "holes overlap"
+	}
+	return "unknown problem"
+}
+
+// ValidationIssue is one problem found by Validate. Ring is 0 for the
+// outer face and 1 onwards for the holes. Index is the vertex or edge
+// (starting at that vertex) where the problem is, and Other is the
+// second edge or ring involved, or -1
+type ValidationIssue struct {
+	Problem ValidationProblem
+	Ring    int
+	Index   int
+	Other   int
+}
+
+func (i ValidationIssue) String() string {
+	ring := "outer"
+	if i.Ring > 0 {
+		ring = fmt.Sprintf("hole %v", i.Ring)
+	}
+	switch i.Problem {
+	case SelfIntersection, HoleCrossesOuter:
+		return fmt.Sprintf("%v: %v between edges %v and %v", ring, i.Problem, i.Index, i.Other)
+	case HolesOverlap:
+		return fmt.Sprintf("%v: %v with hole %v", ring, i.Problem, i.Other)
+	case DuplicateVertex, CollinearVertex, NonPlanar:
+		return fmt.Sprintf("%v: %v at %v", ring, i.Problem, i.Index)
+	}
+	return fmt.Sprintf("%v: %v", ring, i.Problem)
+}
+
+type ValidationError struct {
+	Issues []ValidationIssue
+}
+
+func (e *ValidationError) Error() string {
+	issues := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		issues[i] = issue.String()
+	}
+	return "invalid polygon: " + strings.Join(issues, "; ")
+}
+
+func validationError(issues []ValidationIssue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+	return &ValidationError{issues}
+}
+
+// Validate checks that the face is a simple polygon: at least three
+// vertices, none repeated or lying on the line between its neighbours,
+// and no edges crossing or touching. It returns a *ValidationError
+func (f *Face2D) Validate() error {
+	return validationError(validateRing(f.Vertices, 0, ringTolerance(f.Vertices)))
+}
+
+// Validate checks the outer face and the holes, and also that each hole
+// is wound opposite to the outer, lies inside it, and stays clear of
+// the other holes
+func (s *Shape2D) Validate() error {
+	return validationError(validateShape(s.Faces()))
+}
+
+// Validate checks the face the same way as Face2D.Validate, in the
+// plane of the face, and that all the vertices lie on that plane
+func (f *Face3D) Validate() error {
+	plane := newFacePlane(f.Vertices)
+	issues := validateRing(plane.project(f.Vertices), 0, plane.tolerance)
+	for i, v := range f.Vertices {
+		if math.Abs(v.Subtract(plane.origin).Dot(plane.normal)) > plane.tolerance*1e3 {
+			issues = append(issues, ValidationIssue{NonPlanar, 0, i, -1})
+		}
+	}
+	return validationError(issues)
+}
+
+// Validate checks the outer face and holes, see Shape2D.Validate
+func (n *Node) Validate() error {
+	plane := newFacePlane(n.Outer.Vertices)
+	faces := []*Face2D{{Vertices: plane.project(n.Outer.Vertices)}}
+	for _, inner := range n.Inner {
+		faces = append(faces, &Face2D{Vertices: plane.project(inner.Vertices)})
+	}
+	issues := validateShape(faces)
+	for r, f := range append([]*Face3D{n.Outer}, n.Inner...) {
+		for i, v := range f.Vertices {
+			if math.Abs(v.Subtract(plane.origin).Dot(plane.normal)) > plane.tolerance*1e3 {
+				issues = append(issues, ValidationIssue{NonPlanar, r, i, -1})
+			}
+		}
+	}
+	return validationError(issues)
+}
+
+func ringTolerance(vertices []*Vertex2D) float64 {
+	if len(vertices) == 0 {
+		return 0
+	}
+	minX, minY, maxX, maxY := vertices[0].X, vertices[0].Y, vertices[0].X, vertices[0].Y
+	for _, v := range vertices {
+		minX, minY = math.Min(minX, v.X), math.Min(minY, v.Y)
+		maxX, maxY = math.Max(maxX, v.X), math.Max(maxY, v.Y)
+	}
+	return math.Max(maxX-minX, maxY-minY) * 1e-9
+}
+
+func validateRing(vertices []*Vertex2D, ring int, tolerance float64) []ValidationIssue {
+	n := len(vertices)
+	if n < 3 {
+		return []ValidationIssue{{TooFewVertices, ring, -1, -1}}
+	}
+	var issues []ValidationIssue
+	// edges that aren't just a repeated vertex
+	var edges []int
+	for i, v := range vertices {
+		if v.Distance(vertices[(i+1)%n]) <= tolerance {
+			issues = append(issues, ValidationIssue{DuplicateVertex, ring, (i + 1) % n, -1})
+		} else {
+			edges = append(edges, i)
+		}
+	}
+	if len(edges) < 3 {
+		return append(issues, ValidationIssue{TooFewVertices, ring, -1, -1})
+	}
+	for k, i := range edges {
+		prev := vertices[edges[(k+len(edges)-1)%len(edges)]]
+		v, next := vertices[i], vertices[(i+1)%n]
+		if collinear(prev, v, next, tolerance) {
+			issues = append(issues, ValidationIssue{CollinearVertex, ring, i, -1})
+		}
+	}
+	for k := range edges {
+		for l := k + 2; l < len(edges); l++ {
+			if k == 0 && l == len(edges)-1 {
+				continue
+			}
+			i, j := edges[k], edges[l]
+			if segmentsTouch(vertices[i], vertices[(i+1)%n], vertices[j], vertices[(j+1)%n], tolerance) {
+				issues = append(issues, ValidationIssue{SelfIntersection, ring, i, j})
+			}
+		}
+	}
+	return issues
+}
+
+func validateShape(faces []*Face2D) []ValidationIssue {
+	var all []*Vertex2D
+	for _, f := range faces {
+		all = append(all, f.Vertices...)
+	}
+	tolerance := ringTolerance(all)
+	var issues []ValidationIssue
+	for r, f := range faces {
+		issues = append(issues, validateRing(f.Vertices, r, tolerance)...)
+	}
+	outer := faces[0].Vertices
+	outerArea := signedArea(outer)
+	for r := 1; r < len(faces); r++ {
+		hole := faces[r].Vertices
+		if len(hole) < 3 {
+			continue
+		}
+		if signedArea(hole)*outerArea > 0 {
+			issues = append(issues, ValidationIssue{WrongOrientation, r, -1, -1})
+		}
+		crosses := false
+		for i := range hole {
+			for j := range outer {
+				if segmentsTouch(hole[i], hole[(i+1)%len(hole)], outer[j], outer[(j+1)%len(outer)], tolerance) {
+					issues = append(issues, ValidationIssue{HoleCrossesOuter, r, i, j})
+					crosses = true
+				}
+			}
+		}
+		if !crosses && len(outer) >= 3 && !pointInRing(hole[0].X, hole[0].Y, outer) {
+			issues = append(issues, ValidationIssue{HoleOutsideOuter, r, -1, -1})
+		}
+		for k := r + 1; k < len(faces); k++ {
+			if ringsOverlap(hole, faces[k].Vertices, tolerance) {
+				issues = append(issues, ValidationIssue{HolesOverlap, r, -1, k})
+			}
+		}
+	}
+	return issues
+}
+
+func ringsOverlap(a, b []*Vertex2D, tolerance float64) bool {
+	if len(a) < 3 || len(b) < 3 {
+		return false
+	}
+	for i := range a {
+		for j := range b {
+			if segmentsTouch(a[i], a[(i+1)%len(a)], b[j], b[(j+1)%len(b)], tolerance) {
+				return true
+			}
+		}
+	}
+	return pointInRing(a[0].X, a[0].Y, b) || pointInRing(b[0].X, b[0].Y, a)
+}
+
+func collinear(a, b, c *Vertex2D, tolerance float64) bool {
+	length := math.Max(a.Distance(c), math.Max(a.Distance(b), b.Distance(c)))
+	if length == 0 {
+		return true
+	}
+	cross := (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+	return math.Abs(cross)/length <= tolerance
+}
+
+func segmentDistance(p, a, b *Vertex2D) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	lengthSq := dx*dx + dy*dy
+	t := 0.0
+	if lengthSq > 0 {
+		t = math.Max(0, math.Min(1, ((p.X-a.X)*dx+(p.Y-a.Y)*dy)/lengthSq))
+	}
+	return math.Hypot(a.X+dx*t-p.X, a.Y+dy*t-p.Y)
+}
+
+// segmentsTouch reports whether a-b and c-d cross or come within the
+// tolerance of each other
+func segmentsTouch(a, b, c, d *Vertex2D, tolerance float64) bool {
+	side := func(p, q, r *Vertex2D) float64 {
+		return (q.X-p.X)*(r.Y-p.Y) - (q.Y-p.Y)*(r.X-p.X)
+	}
+	d1, d2 := side(c, d, a), side(c, d, b)
+	d3, d4 := side(a, b, c), side(a, b, d)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	return segmentDistance(a, c, d) <= tolerance || segmentDistance(b, c, d) <= tolerance ||
+		segmentDistance(c, a, b) <= tolerance || segmentDistance(d, a, b) <= tolerance
+}
+
+// Repair removes repeated and collinear vertices, turns the face counter-
+// clockwise, and splits it where it crosses itself, so the result can be
+// several shapes. Vertices that survive keep their labels and UVs
+func (f *Face2D) Repair() []*Shape2D {
+	return NewShape2D(f).Repair()
+}
+
+// Repair cleans up the outer face and holes like Face2D.Repair, and also
+// fixes the winding of holes and cuts away any part of a hole that
+// strays outside the outer
+func (s *Shape2D) Repair() []*Shape2D {
+	var all []*Vertex2D
+	for _, f := range s.Faces() {
+		all = append(all, f.Vertices...)
+	}
+	tolerance := ringTolerance(all)
+	if tolerance == 0 {
+		return nil
+	}
+	originals := newPointPool(tolerance)
+	lookup := map[int]*Vertex2D{}
+	for _, v := range all {
+		if id := originals.add(v.X, v.Y); lookup[id] == nil {
+			lookup[id] = v
+		}
+	}
+	restore := func(vertices []*Vertex2D) *Face2D {
+		res := make([]*Vertex2D, len(vertices))
+		for i, v := range vertices {
+			if id := originals.find(v.X, v.Y); id != -1 {
+				res[i] = lookup[id].Copy()
+				res[i].X, res[i].Y = v.X, v.Y
+			} else {
+				res[i] = v.Copy()
+			}
+		}
+		return &Face2D{Vertices: res}
+	}
+
+	var rings []*Face2D
+	simple := true
+	for _, f := range s.Faces() {
+		vertices := repairRing(f.Vertices, tolerance)
+		if len(vertices) < 3 {
+			if len(rings) == 0 {
+				// nothing left of the outer
+				return nil
+			}
+			continue
+		}
+		rings = append(rings, &Face2D{Vertices: vertices})
+	}
+	issues := validateShape(rings)
+	for _, issue := range issues {
+		if issue.Problem != WrongOrientation {
+			simple = false
+		}
+	}
+	if simple {
+		shape := NewShape2D(restore(rings[0].Vertices))
+		if signedArea(shape.Outer.Vertices) < 0 {
+			reverseVertices(shape.Outer.Vertices)
+		}
+		for _, hole := range rings[1:] {
+			face := restore(hole.Vertices)
+			if signedArea(face.Vertices) > 0 {
+				reverseVertices(face.Vertices)
+			}
+			shape.Holes = append(shape.Holes, face)
+		}
+		return []*Shape2D{shape}
+	}
+
+	// fill by the nonzero rule so every loop of a tangled outer counts,
+	// whichever way it runs
+	inside := func(x, y float64) bool {
+		if windingNumber(x, y, rings[0].Vertices) == 0 {
+			return false
+		}
+		for _, hole := range rings[1:] {
+			if windingNumber(x, y, hole.Vertices) != 0 {
+				return false
+			}
+		}
+		return true
+	}
+	shapes := fillRings(rings, inside)
+	for _, shape := range shapes {
+		shape.Outer = restore(shape.Outer.Vertices)
+		for i, hole := range shape.Holes {
+			shape.Holes[i] = restore(hole.Vertices)
+		}
+	}
+	return shapes
+}
+
+// repairRing drops repeated vertices and vertices in line with their
+// neighbours, including the tips of zero width spikes
+func repairRing(vertices []*Vertex2D, tolerance float64) []*Vertex2D {
+	res := cleanRing(vertices, tolerance)
+	for changed := true; changed && len(res) >= 3; {
+		changed = false
+		for i := 0; i < len(res) && len(res) >= 3; i++ {
+			prev, next := res[(i+len(res)-1)%len(res)], res[(i+1)%len(res)]
+			if collinear(prev, res[i], next, tolerance) {
+				res = cleanRing(append(res[:i:i], res[i+1:]...), tolerance)
+				changed = true
+				i--
+			}
+		}
+	}
+	return res
+}
+
+// Repair fixes the face like Face2D.Repair, working in the plane of the
+// face. Each piece is returned as an unlinked node, with any holes the
+// repair opens up as its Inner faces. The outers are wound the same way
+// as the original, so hole faces stay holes. Vertices that survive are
+// reused, so the face stays connected to its neighbours
+func (f *Face3D) Repair() Nodes {
+	plane := newFacePlane(f.Vertices)
+	projected := plane.project(f.Vertices)
+	var nodes Nodes
+	for _, shape := range (&Face2D{Vertices: projected}).Repair() {
+		node := NewNode(plane.lift(shape.Outer.Vertices, projected, f.Vertices))
+		for _, hole := range shape.Holes {
+			node.Inner = append(node.Inner, plane.lift(hole.Vertices, projected, f.Vertices))
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// Repair fixes the outer face and holes of the node, see Shape2D.Repair.
+// If the node splits into several pieces, the first stays in this node
+// and the rest are inserted after it as new nodes with the same tag
+func (n *Node) Repair() {
+	plane := newFacePlane(n.Outer.Vertices)
+	projected := plane.project(n.Outer.Vertices)
+	originals := append([]*Vertex3D{}, n.Outer.Vertices...)
+	shape := NewShape2D(&Face2D{Vertices: projected})
+	for _, inner := range n.Inner {
+		hole := plane.project(inner.Vertices)
+		shape.Holes = append(shape.Holes, &Face2D{Vertices: hole})
+		projected = append(projected, hole...)
+		originals = append(originals, inner.Vertices...)
+	}
+
+	shapes := shape.Repair()
+	if len(shapes) == 0 {
+		return
+	}
+	var nodes []*Node
+	for _, s := range shapes {
+		node := NewTaggedNode(n.Tag, plane.lift(s.Outer.Vertices, projected, originals))
+		for _, hole := range s.Holes {
+			node.Inner = append(node.Inner, plane.lift(hole.Vertices, projected, originals))
+		}
+		nodes = append(nodes, node)
+	}
+	n.Outer, n.Inner = nodes[0].Outer, nodes[0].Inner
+	for i := len(nodes) - 1; i > 0; i-- {
+		n.InsertAfter(nodes[i])
+	}
+}
+
+// facePlane maps between a face and 2D coordinates in its plane, with
+// counter-clockwise in 2D being counter-clockwise around the normal
+type facePlane struct {
+	origin, u, v, normal *Vertex3D
+	tolerance            float64
+}
+
+func newFacePlane(vertices []*Vertex3D) *facePlane {
+	// newell's method, which still works for concave faces
+	normal := NewVertex3D(0, 0, 0)
+	origin := NewVertex3D(0, 0, 0)
+	size := 0.0
+	for i, v := range vertices {
+		next := vertices[(i+1)%len(vertices)]
+		normal = normal.Add(v.Cross(next))
+		origin = origin.Add(v)
+		size = math.Max(size, v.Distance(next))
+	}
+	if len(vertices) > 0 {
+		origin.Mul(1 / float64(len(vertices)))
+	}
+	if normal.Norm() <= size*size*1e-12 {
+		// a face that folds over onto itself, fall back to any two
+		// edges that are not parallel
+		for i := 2; i < len(vertices); i++ {
+			normal = vertices[1].Subtract(vertices[0]).Cross(vertices[i].Subtract(vertices[0]))
+			if normal.Norm() > size*size*1e-12 {
+				break
+			}
+		}
+	}
+	if normal.Norm() == 0 {
+		normal = NewVertex3D(0, 0, 1)
+	}
+	normal = normal.Normalize()
+	u := NewVertex3D(1, 0, 0)
+	if math.Abs(normal.X) > 0.9 {
+		u = NewVertex3D(0, 1, 0)
+	}
+	u = u.Subtract(NewVertex3D(normal.X*normal.Dot(u), normal.Y*normal.Dot(u), normal.Z*normal.Dot(u))).Normalize()
+	return &facePlane{origin, u, normal.Cross(u), normal, size * 1e-9}
+}
+
+func (p *facePlane) project(vertices []*Vertex3D) []*Vertex2D {
+	res := make([]*Vertex2D, len(vertices))
+	for i, v := range vertices {
+		d := v.Subtract(p.origin)
+		res[i] = NewVertex2D(d.Dot(p.u), d.Dot(p.v))
+	}
+	return res
+}
+
+// lift turns 2D vertices back into 3D, reusing the original vertex
+// wherever one was projected to the same place
+func (p *facePlane) lift(vertices, projected []*Vertex2D, originals []*Vertex3D) *Face3D {
+	pool := newPointPool(p.tolerance)
+	lookup := map[int]*Vertex3D{}
+	for i, v := range projected {
+		if id := pool.add(v.X, v.Y); lookup[id] == nil {
+			lookup[id] = originals[i]
+		}
+	}
+	face := &Face3D{}
+	for _, v := range vertices {
+		if id := pool.find(v.X, v.Y); id != -1 {
+			face.Vertices = append(face.Vertices, lookup[id])
+			continue
+		}
+		face.Vertices = append(face.Vertices, NewVertex3D(
+			p.origin.X+p.u.X*v.X+p.v.X*v.Y,
+			p.origin.Y+p.u.Y*v.X+p.v.Y*v.Y,
+			p.origin.Z+p.u.Z*v.X+p.v.Z*v.Y,
+		))
+	}
+	return face
+}
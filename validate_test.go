@@ -0,0 +1,101 @@
+package toothpaste
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func problems(err error) map[ValidationProblem]int {
+	res := map[ValidationProblem]int{}
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		for _, issue := range verr.Issues {
+			res[issue.Problem]++
+		}
+	}
+	return res
+}
+
+func TestValidate(t *testing.T) {
+	if err := Square(1, 1).Validate(); err != nil {
+		t.Errorf("Expected a square to be valid, got %v", err)
+	}
+
+	bad := NewFace2D(0, 0, 1, 0, 1, 0, 2, 0, 2, 2, 0, 2)
+	found := problems(bad.Validate())
+	if found[DuplicateVertex] != 1 || found[CollinearVertex] != 1 {
+		t.Errorf("Expected a duplicate and a collinear vertex, got %v", bad.Validate())
+	}
+
+	bowtie := NewFace2D(0, 0, 1, 1, 1, 0, 0, 1)
+	if found := problems(bowtie.Validate()); found[SelfIntersection] != 1 {
+		t.Errorf("Expected a self intersection, got %v", bowtie.Validate())
+	}
+
+	hole := Square(1, 1)
+	hole.Translate(1.5, 0.5)
+	found = problems(NewShape2D(Square(2, 2), hole).Validate())
+	if found[HoleCrossesOuter] == 0 || found[WrongOrientation] != 1 {
+		t.Errorf("Expected the hole to cross the outer and be wound wrong, got %v", found)
+	}
+
+	node := NewNode(Square(2, 2).To3D())
+	node.Outer.Vertices[1].Y = 0.5
+	if found := problems(node.Validate()); found[NonPlanar] == 0 {
+		t.Errorf("Expected non planar vertices, got %v", node.Validate())
+	}
+}
+
+func TestRepair(t *testing.T) {
+	bad := NewFace2D(0, 0, 0, 2, 2, 2, 2, 0, 1, 0, 1, 0)
+	bad.Vertices[0].Label = "corner"
+	shapes := bad.Repair()
+	if len(shapes) != 1 {
+		t.Fatalf("Expected 1 shape, got %v", len(shapes))
+	}
+	if err := shapes[0].Validate(); err != nil {
+		t.Errorf("Expected the repaired face to be valid, got %v", err)
+	}
+	if n := len(shapes[0].Outer.Vertices); n != 4 || shapes[0].Outer.IsClockwise() {
+		t.Errorf("Expected 4 counter-clockwise vertices, got %v", shapes[0].Outer.Vertices)
+	}
+	if shapes[0].Outer.Find("corner") == nil {
+		t.Errorf("Expected labels to survive")
+	}
+
+	bowtie := NewFace2D(0, 0, 2, 2, 2, 0, 0, 2)
+	shapes = bowtie.Repair()
+	if len(shapes) != 2 {
+		t.Fatalf("Expected the bowtie to split in 2, got %v", len(shapes))
+	}
+	for _, s := range shapes {
+		if err := s.Validate(); err != nil {
+			t.Errorf("Expected valid pieces, got %v", err)
+		}
+	}
+
+	// a hole poking out of the outer is clipped to it
+	hole := Square(1, 1)
+	hole.Translate(1.5, 0.5)
+	node := NewNode(Square(2, 2).To3D(), hole.To3D())
+	node.Repair()
+	if err := node.Validate(); err != nil {
+		t.Errorf("Expected the node to be valid, got %v", err)
+	}
+	if area := node.Area(); math.Abs(area-3.5) > 1e-9 {
+		t.Errorf("Expected area 3.5, got %v", area)
+	}
+	// a keyhole ring, which repairs into a face with a hole
+	keyhole := NewFace2D(0, 0, 4, 0, 4, 4, 0, 4, 0, 2, 1, 2, 1, 3, 3, 3, 3, 1, 1, 1, 1, 2, 0, 2).To3D()
+	nodes := keyhole.Repair()
+	if len(nodes) != 1 || len(nodes[0].Inner) != 1 {
+		t.Fatalf("Expected 1 node with a hole, got %v", nodes)
+	}
+	if area := nodes[0].Area(); math.Abs(area-12) > 1e-9 {
+		t.Errorf("Expected area 12, got %v", area)
+	}
+	if nodes[0].Outer.Normal().Dot(keyhole.Normal()) < 0.99 {
+		t.Errorf("Expected the outer to keep its winding")
+	}
+}